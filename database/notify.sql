@@ -0,0 +1,44 @@
+-- Change notifications for cache invalidation.
+-- Every insert, update and delete (including cascades) sends a JSON payload
+-- {"table": ..., "id": ..., "op": ...} on the row_changes channel.
+-- Not loaded by the Docker image, so benchmarks run without the trigger cost:
+--   psql -U my_user -d my_database -f notify.sql
+
+-- TG_ARGV holds (table, key column) pairs: the changed row first, then the
+-- parent rows whose cached graphs include it.
+CREATE FUNCTION NOTIFY_ROW_CHANGE() RETURNS TRIGGER AS $$
+DECLARE
+    I INTEGER;
+BEGIN
+    FOR I IN 0 .. TG_NARGS - 1 BY 2 LOOP
+        IF TG_OP <> 'INSERT' THEN
+            PERFORM NOTIFY_KEY(TG_ARGV[I], to_jsonb(OLD) ->> TG_ARGV[I + 1], TG_OP);
+        END IF;
+        IF TG_OP <> 'DELETE' THEN
+            PERFORM NOTIFY_KEY(TG_ARGV[I], to_jsonb(NEW) ->> TG_ARGV[I + 1], TG_OP);
+        END IF;
+    END LOOP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Identical payloads sent in one transaction are delivered once.
+CREATE FUNCTION NOTIFY_KEY(ROW_TABLE TEXT, ROW_KEY TEXT, ROW_OP TEXT) RETURNS VOID AS $$
+BEGIN
+    IF ROW_KEY IS NOT NULL THEN
+        PERFORM pg_notify('row_changes', json_build_object('table', ROW_TABLE, 'id', ROW_KEY::INTEGER, 'op', ROW_OP)::TEXT);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER PROJECTS_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON PROJECTS
+    FOR EACH ROW EXECUTE FUNCTION NOTIFY_ROW_CHANGE('projects', 'id');
+
+CREATE TRIGGER TASKS_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON TASKS
+    FOR EACH ROW EXECUTE FUNCTION NOTIFY_ROW_CHANGE('tasks', 'id', 'projects', 'project_id');
+
+CREATE TRIGGER RESOURCES_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON RESOURCES
+    FOR EACH ROW EXECUTE FUNCTION NOTIFY_ROW_CHANGE('resources', 'id');
+
+CREATE TRIGGER TASK_RESOURCE_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON TASK_RESOURCE
+    FOR EACH ROW EXECUTE FUNCTION NOTIFY_ROW_CHANGE('tasks', 'task_id');
@@ -0,0 +1,122 @@
+-- Functions of the StoredProcedure approach, which sends each project graph
+-- as JSONB and writes it server-side in one round trip. The keys are the JSON
+-- names of the Go entities. Loaded by the Docker image after schema.sql, or
+-- into a running database with:
+--   psql -U my_user -d my_database -f procedures.sql
+
+-- The elements of a JSON array, none for null or a missing key.
+CREATE OR REPLACE FUNCTION JSONB_ITEMS(ITEMS JSONB) RETURNS SETOF JSONB AS $$
+    SELECT jsonb_array_elements(CASE WHEN jsonb_typeof(ITEMS) = 'array' THEN ITEMS ELSE '[]' END);
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Dates are read as TIMESTAMP, which ignores the zone of the RFC 3339 text
+-- that Go writes, and stored as DATE.
+CREATE OR REPLACE FUNCTION INSERT_PROJECT(PROJECT JSONB) RETURNS INTEGER AS $$
+DECLARE
+    NEW_ID INTEGER := (PROJECT ->> 'id')::INTEGER;
+BEGIN
+    INSERT INTO PROJECTS (ID, NAME, MANAGER, START_DATE, END_DATE, BUDGET, DESCRIPTION)
+    SELECT P.id, P.name, P.manager, P."startDate", P."endDate", P.budget, P.description
+    FROM jsonb_to_record(PROJECT) AS P(id INTEGER, name TEXT, manager TEXT, "startDate" TIMESTAMP,
+        "endDate" TIMESTAMP, budget NUMERIC, description TEXT);
+
+    INSERT INTO TASKS (ID, NAME, RESPONSIBLE, DEADLINE, STATUS, PRIORITY, ESTIMATED_TIME, PROJECT_ID, DESCRIPTION)
+    SELECT T.id, T.name, T.responsible, T.deadline, T.status, T.priority, T."estimatedTime", NEW_ID, T.description
+    FROM JSONB_ITEMS(PROJECT -> 'tasks') AS TASK,
+        jsonb_to_record(TASK) AS T(id INTEGER, name TEXT, responsible TEXT, deadline TIMESTAMP, status TEXT,
+            priority TEXT, "estimatedTime" INTERVAL, description TEXT);
+
+    INSERT INTO TASK_RESOURCE (TASK_ID, RESOURCE_ID, QUANTITY_USED)
+    SELECT (TASK ->> 'id')::INTEGER, R.id, R.quantity
+    FROM JSONB_ITEMS(PROJECT -> 'tasks') AS TASK,
+        JSONB_ITEMS(TASK -> 'resources') AS RESOURCE,
+        jsonb_to_record(RESOURCE) AS R(id INTEGER, quantity INTEGER);
+
+    RETURN NEW_ID;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Updates the project and its tasks, like the UpdateProject of the other
+-- approaches: links and resources are left as they are.
+CREATE OR REPLACE FUNCTION UPDATE_PROJECT(PROJECT JSONB) RETURNS VOID AS $$
+BEGIN
+    UPDATE PROJECTS
+    SET NAME = P.name, MANAGER = P.manager, START_DATE = P."startDate", END_DATE = P."endDate",
+        BUDGET = P.budget, DESCRIPTION = P.description
+    FROM jsonb_to_record(PROJECT) AS P(id INTEGER, name TEXT, manager TEXT, "startDate" TIMESTAMP,
+        "endDate" TIMESTAMP, budget NUMERIC, description TEXT)
+    WHERE PROJECTS.ID = P.id;
+
+    UPDATE TASKS
+    SET NAME = T.name, RESPONSIBLE = T.responsible, DEADLINE = T.deadline, STATUS = T.status,
+        PRIORITY = T.priority, ESTIMATED_TIME = T."estimatedTime", DESCRIPTION = T.description
+    FROM JSONB_ITEMS(PROJECT -> 'tasks') AS TASK,
+        jsonb_to_record(TASK) AS T(id INTEGER, name TEXT, responsible TEXT, deadline TIMESTAMP, status TEXT,
+            priority TEXT, "estimatedTime" INTERVAL, description TEXT)
+    WHERE TASKS.ID = T.id AND TASKS.PROJECT_ID = (PROJECT ->> 'id')::INTEGER;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Tasks and links go with the project through ON DELETE CASCADE.
+CREATE OR REPLACE FUNCTION DELETE_PROJECT(PROJECT_ID INTEGER) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM PROJECTS WHERE ID = PROJECT_ID;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The project as a JSON document in the shape of the Go entities, with its
+-- tasks and their resources sorted by TASK_ORDER and RESOURCE_ORDER, ORDER BY
+-- terms on the aliases t and r. The terms are spliced into the statement, so
+-- callers must only pass validated column lists.
+CREATE OR REPLACE FUNCTION READ_PROJECT(PROJECT_ID INTEGER, TASK_ORDER TEXT DEFAULT 't.ID',
+    RESOURCE_ORDER TEXT DEFAULT 'r.ID') RETURNS JSON AS $$
+DECLARE
+    DOCUMENT JSON;
+BEGIN
+    EXECUTE format($query$
+        SELECT json_build_object(
+            'id', p.ID,
+            'name', p.NAME,
+            'manager', p.MANAGER,
+            'startDate', to_char(p.START_DATE, 'YYYY-MM-DD"T00:00:00Z"'),
+            'endDate', to_char(p.END_DATE, 'YYYY-MM-DD"T00:00:00Z"'),
+            'budget', p.BUDGET,
+            'description', p.DESCRIPTION,
+            'tasks', (
+                SELECT json_agg(json_build_object(
+                    'id', t.ID,
+                    'name', t.NAME,
+                    'responsible', t.RESPONSIBLE,
+                    'deadline', to_char(t.DEADLINE, 'YYYY-MM-DD"T00:00:00Z"'),
+                    'status', t.STATUS,
+                    'priority', t.PRIORITY,
+                    'estimatedTime', t.ESTIMATED_TIME::TEXT,
+                    'description', t.DESCRIPTION,
+                    'resources', (
+                        SELECT json_agg(json_build_object(
+                            'id', r.ID,
+                            'type', r.TYPE,
+                            'name', r.NAME,
+                            'dailyCost', r.DAILY_COST,
+                            'status', r.STATUS,
+                            'supplier', r.SUPPLIER,
+                            'quantity', r.QUANTITY,
+                            'acquisitionDate', to_char(r.ACQUISITION_DATE, 'YYYY-MM-DD"T00:00:00Z"')
+                        ) ORDER BY %s)
+                        FROM TASK_RESOURCE tr
+                            JOIN RESOURCES r ON r.ID = tr.RESOURCE_ID
+                        WHERE tr.TASK_ID = t.ID
+                    )
+                ) ORDER BY %s)
+                FROM TASKS t
+                WHERE t.PROJECT_ID = p.ID
+            )
+        )
+        FROM PROJECTS p
+        WHERE p.ID = $1
+    $query$, RESOURCE_ORDER, TASK_ORDER)
+    INTO DOCUMENT
+    USING PROJECT_ID;
+    RETURN DOCUMENT;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,105 @@
+// Command benchcmp compares two benchmark runs recorded by cmd/main.go. For
+// each benchmark it prints the median and its 95% confidence interval in both
+// runs, the change of the medians and the p-value of a Mann-Whitney U test,
+// and exits with status 1 when a checked benchmark got significantly worse
+// than the threshold. Without arguments it compares the two latest runs of the
+// history directory.
+//
+//	go run ./cmd/benchcmp -unit ns/op -threshold 0.05 -check 'GORM/*' old.json new.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"m/utils/results"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+)
+
+func main() {
+	unit := flag.String("unit", "ns/op", "unit to compare: ns/op, B/op, allocs/op or a reported metric such as queries/op")
+	threshold := flag.Float64("threshold", 0.05, "relative change of the median that counts as a regression")
+	alpha := flag.Float64("alpha", 0.05, "significance level of the Mann-Whitney U test")
+	check := flag.String("check", "*/*", "comma-separated approach/operation patterns that fail the command when they regress")
+	history := flag.String("history", "benchmark_history", "directory of the runs saved by cmd/main.go")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: benchcmp [flags] [old.json new.json]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	oldPath, newPath, err := runs(flag.Args(), *history)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	old, err := results.ReadJSON(oldPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", oldPath, err)
+		os.Exit(2)
+	}
+	new, err := results.ReadJSON(newPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", newPath, err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("old: %s\nnew: %s\n\n", oldPath, newPath)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "benchmark\told %s\tnew %s\tdelta\tp\tn\t\n", *unit, *unit)
+	var regressions []string
+	for _, c := range results.Compare(old, new, *unit, 0.95) {
+		delta := "~"
+		if c.Significant(*alpha) {
+			delta = fmt.Sprintf("%+.2f%%", c.Delta*100)
+		}
+		marker := ""
+		if c.Regressed(*unit, *alpha, *threshold) && matches(*check, c) {
+			marker = "REGRESSION"
+			regressions = append(regressions, c.Name)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\tp=%.3f\t%d+%d\t%s\n",
+			c.Name, formatSummary(c.Old), formatSummary(c.New), delta, c.P, c.Old.N, c.New.N, marker)
+	}
+	writer.Flush()
+
+	if len(regressions) > 0 {
+		fmt.Printf("\n%d regressions beyond %.1f%%: %s\n", len(regressions), *threshold*100, strings.Join(regressions, ", "))
+		os.Exit(1)
+	}
+}
+
+// runs returns the files to compare: the arguments, or the two latest runs
+// of the history directory.
+func runs(args []string, history string) (string, string, error) {
+	switch len(args) {
+	case 2:
+		return args[0], args[1], nil
+	case 0:
+		paths, err := results.History(history)
+		if err != nil {
+			return "", "", err
+		}
+		if len(paths) < 2 {
+			return "", "", fmt.Errorf("%s holds %d runs, need 2", history, len(paths))
+		}
+		return paths[len(paths)-2], paths[len(paths)-1], nil
+	}
+	return "", "", fmt.Errorf("expected two result files or none, got %d", len(args))
+}
+
+func matches(patterns string, c results.Comparison) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		if ok, _ := path.Match(strings.TrimSpace(pattern), c.Approach+"/"+c.Operation); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSummary prints the median and its confidence interval.
+func formatSummary(s results.Summary) string {
+	return fmt.Sprintf("%.4g [%.4g, %.4g]", s.Median, s.Low, s.High)
+}
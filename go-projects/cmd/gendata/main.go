@@ -0,0 +1,71 @@
+// Command gendata writes a synthetic dataset in the format of tests/input.json.
+// The spec takes the settings of base.ParseDatasetSpec, or a JSON file with
+// every field of base.DatasetSpec, including per-field NULL ratios:
+//
+//	go run ./cmd/gendata -o datasets/x10.json "scale=10,seed=7,links=1-20"
+//	go run ./cmd/gendata -spec spec.json -o datasets/custom.json
+//
+// A spec prefixed with gen: can also be passed to the benchmarks directly, as
+// DATASET or in the datasets of a cmd/main.go matrix.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	base "m/tests/Base"
+	"os"
+)
+
+func main() {
+	output := flag.String("o", "", "output file (default standard output)")
+	specFile := flag.String("spec", "", "JSON file with a base.DatasetSpec")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: gendata [-o file] [-spec spec.json | settings]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	spec, err := readSpec(*specFile, flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	data, err := json.MarshalIndent(base.GenerateDataset(spec), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding dataset: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing dataset: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%d resources and %d projects written to %s\n", spec.Resources, spec.Projects, *output)
+}
+
+func readSpec(specFile string, args []string) (base.DatasetSpec, error) {
+	switch {
+	case specFile != "" && len(args) > 0:
+		return base.DatasetSpec{}, fmt.Errorf("use either -spec or settings")
+	case specFile != "":
+		spec := base.DefaultDatasetSpec
+		data, err := os.ReadFile(specFile)
+		if err != nil {
+			return spec, err
+		}
+		err = json.Unmarshal(data, &spec)
+		return spec, err
+	case len(args) > 1:
+		return base.DatasetSpec{}, fmt.Errorf("expected one comma-separated list of settings, got %d arguments", len(args))
+	case len(args) == 1:
+		return base.ParseDatasetSpec(args[0])
+	}
+	return base.DefaultDatasetSpec, nil
+}
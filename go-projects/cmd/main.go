@@ -1,59 +1,205 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"m/utils/explain"
+	"m/utils/matrix"
+	"m/utils/results"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
 func main() {
-	// Define the test directories and the commands you want to run
-	tests := []string{
-		"tests/DAONotation",
-		"tests/DirectStruct",
-		"tests/GORM",
-		"tests/SQLRepository",
-	}
+	configPath := flag.String("config", "", "JSON file with the benchmark matrix; flags override its fields")
+	approaches := flag.String("approaches", "", "comma-separated approaches, directories under tests (default all)")
+	operations := flag.String("operations", "", "comma-separated operations, such as ReadProject,InsertProject (default all)")
+	datasets := flag.String("datasets", "", "comma-separated input files in the format of tests/input.json, or gen: specs of generated datasets (default tests/input.json)")
+	pools := flag.String("pools", "", "comma-separated maximum numbers of open connections (default driver's)")
+	idle := flag.String("idle", "", "comma-separated maximum numbers of idle connections (default the pool size)")
+	lifetimes := flag.String("lifetimes", "", "comma-separated maximum connection lifetimes, such as 30s,5m (default driver's)")
+	cpus := flag.String("cpus", "", "comma-separated GOMAXPROCS values, as go test -cpu")
+	count := flag.Int("count", 1, "run each benchmark n times; cmd/benchcmp needs 5 or more to tell changes from noise")
+	benchtime := flag.String("benchtime", "", "run time or iterations of each benchmark, as go test -benchtime")
+	history := flag.String("history", "benchmark_history", "directory that keeps the results of every run for cmd/benchcmp")
+	explainPlans := flag.Bool("explain", false, "capture the EXPLAIN ANALYZE plan of every statement into explain_<job>.json; timings of such runs are not comparable")
+	flag.Parse()
 
-	// Set the log file name and open it for writing
-	logFileName := "benchmark_results.log"
-	logFile, err := os.Create(logFileName)
+	config := matrix.Config{Count: 1}
+	if *configPath != "" {
+		loaded, err := matrix.Load(*configPath)
+		if err != nil {
+			fmt.Printf("Error loading config: %v\n", err)
+			os.Exit(2)
+		}
+		config = loaded
+	}
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "approaches":
+			config.Approaches = splitList(*approaches)
+		case "operations":
+			config.Operations = splitList(*operations)
+		case "datasets":
+			config.Datasets = splitDatasets(*datasets)
+		case "pools":
+			config.PoolSizes, err = splitInts(*pools, err)
+		case "idle":
+			config.IdleConns, err = splitInts(*idle, err)
+		case "lifetimes":
+			config.Lifetimes = splitList(*lifetimes)
+		case "cpus":
+			config.CPUs, err = splitInts(*cpus, err)
+		case "count":
+			config.Count = *count
+		case "benchtime":
+			config.Benchtime = *benchtime
+		}
+	})
 	if err != nil {
-		fmt.Printf("Error creating log file: %v\n", err)
-		return
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
 	}
-	defer logFile.Close()
 
-	for _, testDir := range tests {
-		fmt.Printf("Running tests in the directory: %s\n", testDir)
-		result := runBenchmark(testDir)
-		fmt.Println(result)
+	// Results are written to benchmark_results.json and benchmark_results.csv
+	resultsPath := "benchmark_results"
+	run := results.Run{GoVersion: goVersion(), Commit: commit(), Timestamp: time.Now().UTC()}
+	var records []results.Record
+
+	for _, job := range config.Expand() {
+		fmt.Printf("Running %s: go %s\n", job.Label(), strings.Join(job.Args(), " "))
+		env, err := job.Env()
+		if err != nil {
+			fmt.Printf("Error preparing %s: %v\n", job.Label(), err)
+			continue
+		}
+		planFile := ""
+		if *explainPlans {
+			planFile, _ = filepath.Abs("explain_" + job.Label() + ".json")
+			env = append(env, "EXPLAIN_FILE="+planFile)
+		}
+		output, err := runBenchmark(job, env)
+		fmt.Println(output)
+		if err != nil {
+			fmt.Printf("Error running benchmark on %s: %v\n", job.Dir(), err)
+		}
 
-		writeToLog(logFile, testDir, result)
+		parsed, err := results.Parse(strings.NewReader(output), job.ResultApproach(), job.Params(), run)
+		if err != nil {
+			fmt.Printf("Error parsing the results of %s: %v\n", job.Label(), err)
+		}
+		records = append(records, parsed...)
+		if planFile != "" {
+			reportScans(planFile)
+		}
+	}
+
+	if err := results.WriteFiles(resultsPath, records); err != nil {
+		fmt.Printf("Error writing results: %v\n", err)
+		return
 	}
+	fmt.Printf("%d benchmark results were recorded in: %s.json and %s.csv\n", len(records), resultsPath, resultsPath)
 
-	fmt.Printf("Benchmark results were recorded in: %s\n", logFileName)
+	if *history != "" {
+		saved, err := results.SaveHistory(*history, run, records)
+		if err != nil {
+			fmt.Printf("Error saving history: %v\n", err)
+			return
+		}
+		fmt.Printf("Run saved as %s; compare it with the previous one with: go run ./cmd/benchcmp\n", saved)
+	}
 }
 
-func runBenchmark(testDir string) string {
-	cmd := exec.Command("go", "test", "-benchmem", "-run=^_test$", "-bench", ".", "./...")
-	cmd.Dir = testDir
+// runBenchmark returns the output of the benchmarks, which may hold results
+// even when some of them failed.
+func runBenchmark(job matrix.Job, env []string) (string, error) {
+	cmd := exec.Command("go", job.Args()...)
+	cmd.Dir = job.Dir()
+	cmd.Env = append(os.Environ(), env...)
 
 	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Sprintf("Error running benchmark on %s: %v\n", testDir, err)
+	return string(output), err
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	return string(output)
+// splitDatasets splits a list of datasets, keeping the settings of a gen:
+// spec together: "input.json,gen:scale=4,seed=7" holds two datasets.
+func splitDatasets(list string) []string {
+	var datasets []string
+	for _, item := range splitList(list) {
+		last := len(datasets) - 1
+		if last >= 0 && strings.HasPrefix(datasets[last], "gen:") && !strings.HasPrefix(item, "gen:") && strings.Contains(item, "=") {
+			datasets[last] += "," + item
+			continue
+		}
+		datasets = append(datasets, item)
+	}
+	return datasets
 }
 
-func writeToLog(logFile *os.File, testDir string, result string) {
-	timestamp := time.Now().Format(time.RFC3339)
-	logEntry := fmt.Sprintf("\n\n==== Benchmark Results for %s ====\nTimestamp: %s\n%s\n", testDir, timestamp, result)
+// splitInts parses a comma-separated list of numbers, keeping the first error.
+func splitInts(list string, err error) ([]int, error) {
+	var numbers []int
+	for _, item := range splitList(list) {
+		number, convErr := strconv.Atoi(item)
+		if convErr != nil && err == nil {
+			err = fmt.Errorf("invalid number %q", item)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, err
+}
 
-	_, err := logFile.WriteString(logEntry)
+// goVersion is the version of the go command that runs the benchmarks.
+func goVersion() string {
+	output, err := exec.Command("go", "env", "GOVERSION").Output()
 	if err != nil {
-		fmt.Printf("Error writing to log file: %v\n", err)
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// commit is the checked out commit, marked "-dirty" when the tree has changes.
+func commit() string {
+	output, err := exec.Command("git", "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	revision := strings.TrimSpace(string(output))
+	if status, err := exec.Command("git", "status", "--porcelain").Output(); err == nil && len(status) > 0 {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// reportScans prints the statements whose plan has a flagged sequential scan.
+func reportScans(planFile string) {
+	plans, err := explain.ReadFile(planFile)
+	if err != nil {
+		fmt.Printf("Error reading plans: %v\n", err)
+		return
+	}
+
+	flagged := 0
+	for _, plan := range plans {
+		for _, scan := range plan.Flags {
+			flagged++
+			fmt.Printf("Seq Scan on %s (%s) in %s/%s: %s\n", scan.Relation, scan.Condition, plan.Approach, plan.Operation, plan.Query)
+		}
 	}
+	fmt.Printf("%d plans recorded in %s, %d flagged sequential scans\n", len(plans), planFile, flagged)
 }
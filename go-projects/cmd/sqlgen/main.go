@@ -0,0 +1,76 @@
+// Command sqlgen generates typed Go functions from annotated SQL queries and
+// the tables of a schema, in the style of sqlc:
+//
+//	go run ./cmd/sqlgen -schema ../database/schema.sql -queries tests/SQLGen/db/queries.sql -o tests/SQLGen/db/queries.go
+//
+// The package is named after the output directory unless -package is given.
+// It is run by go generate in tests/SQLGen/db.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"m/utils/sqlgen"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	schemaFile := flag.String("schema", "", "SQL file with the CREATE TABLE statements")
+	queriesFile := flag.String("queries", "", "SQL file with the annotated queries")
+	output := flag.String("o", "", "output file (default standard output)")
+	pkg := flag.String("package", "", "package name (default the name of the output directory)")
+	flag.Parse()
+
+	if *schemaFile == "" || *queriesFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: sqlgen -schema schema.sql -queries queries.sql [-o file] [-package name]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if *pkg == "" {
+		*pkg = "db"
+		if *output != "" {
+			absolute, err := filepath.Abs(*output)
+			if err == nil {
+				*pkg = filepath.Base(filepath.Dir(absolute))
+			}
+		}
+	}
+
+	source, err := generate(*schemaFile, *queriesFile, *pkg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(source)
+		return
+	}
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+}
+
+func generate(schemaFile string, queriesFile string, pkg string) ([]byte, error) {
+	schemaSQL, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := sqlgen.ParseSchema(string(schemaSQL))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", schemaFile, err)
+	}
+
+	queriesSQL, err := os.ReadFile(queriesFile)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := sqlgen.ParseQueries(string(queriesSQL), schema)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", queriesFile, err)
+	}
+
+	return sqlgen.Generate(pkg, filepath.Base(queriesFile), queries)
+}
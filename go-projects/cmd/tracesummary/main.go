@@ -0,0 +1,35 @@
+// Command tracesummary prints where the time recorded in a TRACE_FILE went,
+// per span name. Self is the time not spent in child spans, such as the
+// reflection of a DAO call around its SQL statements.
+//
+//	go run ./cmd/tracesummary spans.jsonl
+package main
+
+import (
+	"fmt"
+	"m/utils/trace"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: tracesummary <trace file>")
+		os.Exit(2)
+	}
+
+	spans, err := trace.ReadJSONFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading trace file: %v\n", err)
+		os.Exit(1)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "span\tcount\ttotal\tself\tavg self\t")
+	for _, summary := range trace.Summarize(spans) {
+		avgSelf := summary.Self / time.Duration(summary.Count)
+		fmt.Fprintf(writer, "%s\t%d\t%v\t%v\t%v\t\n", summary.Name, summary.Count, summary.Total, summary.Self, avgSelf)
+	}
+	writer.Flush()
+}
@@ -3,6 +3,7 @@ module m
 go 1.21.0
 
 require (
+	github.com/jackc/pgx/v5 v5.4.3
 	github.com/lib/pq v1.10.9
 	gorm.io/driver/postgres v1.5.6
 	gorm.io/gorm v1.25.7
@@ -11,9 +12,10 @@ require (
 require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
-	github.com/jackc/pgx/v5 v5.4.3 // indirect
+	github.com/jackc/puddle/v2 v2.2.1 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	golang.org/x/crypto v0.14.0 // indirect
+	golang.org/x/sync v0.2.0 // indirect
 	golang.org/x/text v0.13.0 // indirect
 )
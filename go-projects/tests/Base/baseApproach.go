@@ -0,0 +1,252 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"m/utils/orderby"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Approach is one implementation of the repository operations, as driven by
+// the shared suite in tests/suite. Open connects and converts the input data
+// into the approach's own entities once, so that the operations take the index
+// of a resource or project and measure nothing but the repository call.
+type Approach interface {
+	Open(tb testing.TB, data TestInput)
+	Close() error
+	// DB is the database/sql handle of the connection, for cleaning up.
+	DB() *sql.DB
+
+	InsertResource(i int) error
+	InsertProject(i int) error
+	// ReadProject reads project i back, to be compared with Project(i).
+	ReadProject(i int) (interface{}, error)
+	// UpdateProject stores project i as changed by UpdatedProject.
+	UpdateProject(i int) error
+	DeleteProject(i int) error
+	Project(i int) interface{}
+}
+
+// QueryBudgeter is implemented by approaches that pin the round trips of
+// ReadProject; ok is false when they do not.
+type QueryBudgeter interface {
+	ReadProjectBudget(project BaseProject) (budget int64, ok bool)
+}
+
+// PoolWaiter is implemented by approaches that tell how many times, and for
+// how long in total, their operations waited for a free connection.
+type PoolWaiter interface {
+	PoolWait() (count int64, wait time.Duration)
+}
+
+var approaches = make(map[string]func() Approach)
+
+// RegisterApproach makes an approach available to the suite and to Seed under
+// name. It is called from the init functions of the approach*.go adapters in
+// tests/suite, so packages that use an approach by name import that package.
+func RegisterApproach(name string, factory func() Approach) {
+	if _, exists := approaches[name]; exists {
+		panic(fmt.Sprintf("approach %s registered twice", name))
+	}
+	approaches[name] = factory
+}
+
+// Approaches lists the registered approaches by name.
+func Approaches() []string {
+	names := make([]string, 0, len(approaches))
+	for name := range approaches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunApproaches runs fn as a sub-benchmark per registered approach, opened on
+// the input data and labelled with the approach name in Metrics and Explain.
+func RunApproaches(b *testing.B, fn func(b *testing.B, approach Approach, data TestInput)) {
+	data := GetInputData(b)
+	for _, name := range Approaches() {
+		b.Run(name, func(b *testing.B) {
+			withApproach(b, name, data, func(approach Approach) { fn(b, approach, data) })
+		})
+	}
+}
+
+// TestApproaches runs fn as a subtest per registered approach, like
+// RunApproaches.
+func TestApproaches(t *testing.T, fn func(t *testing.T, approach Approach, data TestInput)) {
+	data := GetInputData(t)
+	for _, name := range Approaches() {
+		t.Run(name, func(t *testing.T) {
+			withApproach(t, name, data, func(approach Approach) { fn(t, approach, data) })
+		})
+	}
+}
+
+func withApproach(tb testing.TB, name string, data TestInput, fn func(Approach)) {
+	previous := approach
+	approach = name
+	defer func() { approach = previous }()
+
+	instance := approaches[name]()
+	instance.Open(tb, data)
+	defer instance.Close()
+	fn(instance)
+}
+
+// Seed replaces the contents of the database with the input data, stored
+// through the named approach, for benchmarks that need it in place.
+func Seed(tb testing.TB, name string) {
+	tb.Helper()
+	factory, ok := approaches[name]
+	if !ok {
+		tb.Fatalf("Unknown approach %q", name)
+	}
+	data := GetInputData(tb)
+	instance := factory()
+	instance.Open(tb, data)
+	defer instance.Close()
+	Prepare(tb, instance, data, WithProjects)
+}
+
+// UpdatedProject is the change that UpdateProject benchmarks apply: a new
+// name, a new deadline for the first task and a new daily cost for its first
+// resource. It returns a copy and leaves project untouched.
+func UpdatedProject(project BaseProject) BaseProject {
+	updated, err := Cast[BaseProject](project)
+	if err != nil {
+		panic(err)
+	}
+	updated.Name = "new name"
+	if len(updated.Tasks) > 0 {
+		updated.Tasks[0].Deadline = time.Now()
+		if len(updated.Tasks[0].Resources) > 0 {
+			updated.Tasks[0].Resources[0].DailyCost = new(float64)
+			*updated.Tasks[0].Resources[0].DailyCost = 3.14
+		}
+	}
+	return updated
+}
+
+// Funcs are the repository functions of an approach working on a DB handle,
+// resource entities R and project entities P. Adapt turns them into an
+// Approach.
+type Funcs[DB any, R any, P any] struct {
+	Open  func() DB
+	Close func(DB) error
+	// SQL returns a database/sql handle on the same database, for cleaning
+	// up. It is called once per Open and closed along with the connection.
+	SQL func(DB) (*sql.DB, error)
+
+	InsertResource func(DB, R) (int, error)
+	InsertProject  func(DB, P) (int, error)
+	ReadProject    func(DB, int, ...orderby.OrderBy) (*P, error)
+	UpdateProject  func(DB, *P) error
+	DeleteProject  func(DB, int) error
+
+	// ReadBudget is the number of round trips of ReadProject, if pinned.
+	ReadBudget func(BaseProject) int64
+	// PoolWait reports the waits for a connection of pools outside
+	// database/sql. By default they are read from the SQL handle.
+	PoolWait func(DB) (int64, time.Duration)
+}
+
+// Adapt returns an Approach running funcs.
+func Adapt[DB any, R any, P any](funcs Funcs[DB, R, P]) Approach {
+	return &adapter[DB, R, P]{funcs: funcs}
+}
+
+type adapter[DB any, R any, P any] struct {
+	funcs     Funcs[DB, R, P]
+	db        DB
+	sqlDB     *sql.DB
+	ids       []int
+	resources []R
+	projects  []P
+	updates   []P
+}
+
+func (a *adapter[DB, R, P]) Open(tb testing.TB, data TestInput) {
+	tb.Helper()
+	var err error
+	if a.resources, err = Cast[[]R](data.Resources); err != nil {
+		tb.Fatalf("Failed to cast resources: %v", err)
+	}
+	if a.projects, err = Cast[[]P](data.Projects); err != nil {
+		tb.Fatalf("Failed to cast projects: %v", err)
+	}
+	updates := make([]BaseProject, len(data.Projects))
+	a.ids = make([]int, len(data.Projects))
+	for i, project := range data.Projects {
+		updates[i] = UpdatedProject(project)
+		a.ids[i] = project.ID
+	}
+	if a.updates, err = Cast[[]P](updates); err != nil {
+		tb.Fatalf("Failed to cast updated projects: %v", err)
+	}
+	a.db = a.funcs.Open()
+	if a.sqlDB, err = a.funcs.SQL(a.db); err != nil {
+		tb.Fatalf("Failed to get database handle: %v", err)
+	}
+}
+
+// Close closes the connection and the database/sql handle, which is either
+// the same pool or, for drivers outside database/sql, a second one.
+func (a *adapter[DB, R, P]) Close() error {
+	err := a.funcs.Close(a.db)
+	if closeErr := a.sqlDB.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (a *adapter[DB, R, P]) DB() *sql.DB {
+	return a.sqlDB
+}
+
+func (a *adapter[DB, R, P]) InsertResource(i int) error {
+	_, err := a.funcs.InsertResource(a.db, a.resources[i])
+	return err
+}
+
+func (a *adapter[DB, R, P]) InsertProject(i int) error {
+	_, err := a.funcs.InsertProject(a.db, a.projects[i])
+	return err
+}
+
+func (a *adapter[DB, R, P]) ReadProject(i int) (interface{}, error) {
+	project, err := a.funcs.ReadProject(a.db, a.ids[i])
+	if err != nil {
+		return nil, err
+	}
+	return *project, nil
+}
+
+func (a *adapter[DB, R, P]) UpdateProject(i int) error {
+	return a.funcs.UpdateProject(a.db, &a.updates[i])
+}
+
+func (a *adapter[DB, R, P]) DeleteProject(i int) error {
+	return a.funcs.DeleteProject(a.db, a.ids[i])
+}
+
+func (a *adapter[DB, R, P]) Project(i int) interface{} {
+	return a.projects[i]
+}
+
+func (a *adapter[DB, R, P]) ReadProjectBudget(project BaseProject) (int64, bool) {
+	if a.funcs.ReadBudget == nil {
+		return 0, false
+	}
+	return a.funcs.ReadBudget(project), true
+}
+
+func (a *adapter[DB, R, P]) PoolWait() (int64, time.Duration) {
+	if a.funcs.PoolWait != nil {
+		return a.funcs.PoolWait(a.db)
+	}
+	stats := a.sqlDB.Stats()
+	return stats.WaitCount, stats.WaitDuration
+}
@@ -0,0 +1,251 @@
+package base
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is an inclusive range of counts, drawn uniformly.
+type Range struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+func (r Range) draw(rng *rand.Rand) int {
+	if r.Max <= r.Min {
+		return r.Min
+	}
+	return r.Min + rng.Intn(r.Max-r.Min+1)
+}
+
+// NullRatios are the probabilities of each optional field being NULL.
+type NullRatios struct {
+	EndDate         float64 `json:"endDate"`
+	Budget          float64 `json:"budget"`
+	Description     float64 `json:"description"` // of projects and tasks
+	Responsible     float64 `json:"responsible"`
+	Priority        float64 `json:"priority"`
+	EstimatedTime   float64 `json:"estimatedTime"`
+	DailyCost       float64 `json:"dailyCost"`
+	Supplier        float64 `json:"supplier"`
+	Quantity        float64 `json:"quantity"`
+	AcquisitionDate float64 `json:"acquisitionDate"`
+}
+
+// DatasetSpec describes a synthetic dataset. The same spec and seed always
+// produce the same data.
+type DatasetSpec struct {
+	Seed             int64      `json:"seed"`
+	Resources        int        `json:"resources"`
+	Projects         int        `json:"projects"`
+	TasksPerProject  Range      `json:"tasksPerProject"`
+	ResourcesPerTask Range      `json:"resourcesPerTask"`
+	Nulls            NullRatios `json:"nulls"`
+}
+
+// DefaultDatasetSpec resembles tests/input.json: 500 resources, 10 projects of
+// 50 tasks, each task linked to 2 to 10 resources.
+var DefaultDatasetSpec = DatasetSpec{
+	Seed:             1,
+	Resources:        500,
+	Projects:         10,
+	TasksPerProject:  Range{Min: 50, Max: 50},
+	ResourcesPerTask: Range{Min: 2, Max: 10},
+	Nulls:            NullRatios{Responsible: 0.2, Supplier: 0.3},
+}
+
+// Scale multiplies the number of resources and projects by factor, keeping
+// the shape of each project.
+func (s DatasetSpec) Scale(factor float64) DatasetSpec {
+	s.Resources = int(math.Round(float64(s.Resources) * factor))
+	s.Projects = int(math.Round(float64(s.Projects) * factor))
+	return s
+}
+
+// DatasetPrefix marks a DATASET value as a spec to generate rather than a file.
+const DatasetPrefix = "gen:"
+
+// ParseDatasetSpec reads comma-separated settings over DefaultDatasetSpec:
+// seed=N, scale=F, resources=N, projects=N, tasks=N or MIN-MAX (per
+// project), links=N or MIN-MAX (resources per task) and nulls=F, the NULL
+// ratio of every optional field. Scale applies after the counts.
+func ParseDatasetSpec(text string) (DatasetSpec, error) {
+	spec := DefaultDatasetSpec
+	scale := 1.0
+	for _, setting := range strings.Split(strings.TrimPrefix(text, DatasetPrefix), ",") {
+		if setting = strings.TrimSpace(setting); setting == "" {
+			continue
+		}
+		key, value, found := strings.Cut(setting, "=")
+		if !found {
+			return spec, fmt.Errorf("invalid dataset setting %q", setting)
+		}
+
+		var err error
+		switch key {
+		case "seed":
+			spec.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "scale":
+			scale, err = strconv.ParseFloat(value, 64)
+		case "resources":
+			spec.Resources, err = strconv.Atoi(value)
+		case "projects":
+			spec.Projects, err = strconv.Atoi(value)
+		case "tasks":
+			spec.TasksPerProject, err = parseRange(value)
+		case "links":
+			spec.ResourcesPerTask, err = parseRange(value)
+		case "nulls":
+			var ratio float64
+			ratio, err = strconv.ParseFloat(value, 64)
+			spec.Nulls = NullRatios{ratio, ratio, ratio, ratio, ratio, ratio, ratio, ratio, ratio, ratio}
+		default:
+			err = fmt.Errorf("unknown setting")
+		}
+		if err != nil {
+			return spec, fmt.Errorf("invalid dataset setting %q: %v", setting, err)
+		}
+	}
+	spec = spec.Scale(scale)
+	return spec, spec.validate()
+}
+
+func (s DatasetSpec) validate() error {
+	if s.Resources < 0 || s.Projects < 0 || s.TasksPerProject.Min < 0 || s.ResourcesPerTask.Min < 0 {
+		return fmt.Errorf("dataset counts must not be negative: %+v", s)
+	}
+	n := s.Nulls
+	for _, ratio := range []float64{n.EndDate, n.Budget, n.Description, n.Responsible, n.Priority, n.EstimatedTime, n.DailyCost, n.Supplier, n.Quantity, n.AcquisitionDate} {
+		if ratio < 0 || ratio > 1 {
+			return fmt.Errorf("NULL ratios must be between 0 and 1: %+v", n)
+		}
+	}
+	return nil
+}
+
+func parseRange(value string) (Range, error) {
+	low, high, found := strings.Cut(value, "-")
+	min, err := strconv.Atoi(low)
+	if err != nil || !found {
+		return Range{Min: min, Max: min}, err
+	}
+	max, err := strconv.Atoi(high)
+	if err == nil && max < min {
+		err = fmt.Errorf("%d is below %d", max, min)
+	}
+	return Range{Min: min, Max: max}, err
+}
+
+var (
+	resourceTypes    = []string{"labor", "equipment", "hardware", "software"}
+	resourceStatuses = []string{"available", "in use", "maintenance"}
+	suppliers        = []string{"Supplier A", "Supplier B", "Supplier C"}
+	managers         = []string{"Manager A", "Manager B", "Manager C"}
+	people           = []string{"Alice", "Bob", "Charlie", "Dave", "Eve"}
+	taskStatuses     = []string{"pending", "in progress", "completed"}
+	priorities       = []string{"low", "medium", "high"}
+	datasetEpoch     = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// GenerateDataset builds the data described by spec in the shape of
+// tests/input.json. IDs are sequential from 1, and the resources of a task
+// are copies of distinct entries of Resources.
+func GenerateDataset(spec DatasetSpec) TestInput {
+	rng := rand.New(rand.NewSource(spec.Seed))
+	nulls := spec.Nulls
+	data := TestInput{Resources: make([]BaseResource, spec.Resources), Projects: make([]BaseProject, spec.Projects)}
+
+	for i := range data.Resources {
+		resource := &data.Resources[i]
+		resource.ID = i + 1
+		resource.Type = pick(rng, resourceTypes)
+		resource.Name = fmt.Sprintf("Resource %d", resource.ID)
+		resource.Status = pick(rng, resourceStatuses)
+		resource.DailyCost = maybe(rng, nulls.DailyCost, money(rng, 50, 500))
+		resource.Supplier = maybe(rng, nulls.Supplier, pick(rng, suppliers))
+		resource.Quantity = maybe(rng, nulls.Quantity, 1+rng.Intn(100))
+		resource.AcquisitionDate = maybe(rng, nulls.AcquisitionDate, day(rng, 0, 2500))
+	}
+
+	links := spec.ResourcesPerTask
+	if links.Max > spec.Resources {
+		links.Max = spec.Resources
+	}
+	if links.Min > links.Max {
+		links.Min = links.Max
+	}
+
+	taskID := 0
+	for i := range data.Projects {
+		project := &data.Projects[i]
+		project.ID = i + 1
+		project.Name = fmt.Sprintf("Project %d", project.ID)
+		project.Manager = pick(rng, managers)
+		project.StartDate = day(rng, 0, 2500)
+		project.EndDate = maybe(rng, nulls.EndDate, project.StartDate.AddDate(0, 0, 90+rng.Intn(810)))
+		project.Budget = maybe(rng, nulls.Budget, money(rng, 10000, 500000))
+		project.Description = maybe(rng, nulls.Description, fmt.Sprintf("Description for Project %d", project.ID))
+
+		project.Tasks = make([]BaseTask, spec.TasksPerProject.draw(rng))
+		for j := range project.Tasks {
+			taskID++
+			task := &project.Tasks[j]
+			task.ID = taskID
+			task.Name = fmt.Sprintf("Task %d", task.ID)
+			task.Responsible = maybe(rng, nulls.Responsible, pick(rng, people))
+			task.Deadline = project.StartDate.AddDate(0, 0, 30+rng.Intn(1200))
+			task.Status = pick(rng, taskStatuses)
+			task.Priority = maybe(rng, nulls.Priority, pick(rng, priorities))
+			task.EstimatedTime = maybe(rng, nulls.EstimatedTime, fmt.Sprintf("%d days", 1+rng.Intn(10)))
+			task.Description = maybe(rng, nulls.Description, fmt.Sprintf("Description for Task %d", task.ID))
+
+			task.Resources = make([]BaseResource, links.draw(rng))
+			for k, index := range distinct(rng, spec.Resources, len(task.Resources)) {
+				task.Resources[k] = data.Resources[index]
+			}
+		}
+	}
+	return data
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.Intn(len(values))]
+}
+
+// maybe returns nil with probability ratio, and a pointer to value otherwise.
+// The value is computed either way so that ratios do not shift the sequence
+// of the other fields.
+func maybe[T any](rng *rand.Rand, ratio float64, value T) *T {
+	if rng.Float64() < ratio {
+		return nil
+	}
+	return &value
+}
+
+func money(rng *rand.Rand, min, max float64) float64 {
+	return math.Round((min+rng.Float64()*(max-min))*100) / 100
+}
+
+func day(rng *rand.Rand, min, max int) time.Time {
+	return datasetEpoch.AddDate(0, 0, min+rng.Intn(max-min))
+}
+
+// distinct draws k distinct indices below n, in the order drawn.
+func distinct(rng *rand.Rand, n, k int) []int {
+	if k*2 > n {
+		return rng.Perm(n)[:k]
+	}
+	seen := make(map[int]bool, k)
+	indices := make([]int, 0, k)
+	for len(indices) < k {
+		if index := rng.Intn(n); !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,66 @@
+package base
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateDataset(t *testing.T) {
+	spec, err := ParseDatasetSpec("gen:seed=7,projects=3,resources=40,tasks=2-5,links=1-40,nulls=0.5")
+	if err != nil {
+		t.Fatalf("ParseDatasetSpec failed: %v", err)
+	}
+	data := GenerateDataset(spec)
+	if !reflect.DeepEqual(data, GenerateDataset(spec)) {
+		t.Fatal("Expected the same seed to generate the same data")
+	}
+	spec.Seed++
+	if reflect.DeepEqual(data, GenerateDataset(spec)) {
+		t.Error("Expected another seed to generate other data")
+	}
+
+	if len(data.Resources) != 40 || len(data.Projects) != 3 {
+		t.Fatalf("Unexpected counts: %d resources, %d projects", len(data.Resources), len(data.Projects))
+	}
+	nextTask, nulls := 1, 0
+	for _, project := range data.Projects {
+		if len(project.Tasks) < 2 || len(project.Tasks) > 5 {
+			t.Errorf("Project %d has %d tasks, outside 2-5", project.ID, len(project.Tasks))
+		}
+		for _, task := range project.Tasks {
+			if task.ID != nextTask {
+				t.Errorf("Expected task ID %d, got %d", nextTask, task.ID)
+			}
+			nextTask++
+			if task.Responsible == nil {
+				nulls++
+			}
+			seen := make(map[int]bool)
+			for _, resource := range task.Resources {
+				if seen[resource.ID] || resource != data.Resources[resource.ID-1] {
+					t.Errorf("Task %d links resource %d twice or with other values", task.ID, resource.ID)
+				}
+				seen[resource.ID] = true
+			}
+		}
+	}
+	if nulls == 0 || nulls == nextTask-1 {
+		t.Errorf("Expected about half of the responsibles to be NULL, got %d of %d", nulls, nextTask-1)
+	}
+}
+
+func TestParseDatasetSpec(t *testing.T) {
+	spec, err := ParseDatasetSpec("scale=2.5,tasks=20")
+	if err != nil {
+		t.Fatalf("ParseDatasetSpec failed: %v", err)
+	}
+	if spec.Resources != 1250 || spec.Projects != 25 || spec.TasksPerProject != (Range{20, 20}) || spec.Seed != DefaultDatasetSpec.Seed {
+		t.Errorf("Unexpected spec: %+v", spec)
+	}
+
+	for _, text := range []string{"scale", "tasks=9-3", "nulls=2", "colour=red", "projects=-1"} {
+		if _, err := ParseDatasetSpec(text); err == nil {
+			t.Errorf("Expected an error for %q", text)
+		}
+	}
+}
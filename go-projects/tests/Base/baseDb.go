@@ -1,19 +1,210 @@
 package base
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"m/utils/querylog"
+	"m/utils/trace"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
+// PsqlInfo is the connection string of the test database.
+const PsqlInfo = "host=localhost port=5432 user=my_user password=my@Pass%1234 dbname=my_database sslmode=disable"
+
+// QueryLogger receives the statements of the databases opened by SetupDB,
+// SetupGorm and SetupPgx. It is read from QUERY_LOG: unset disables logging,
+// "all" logs every statement and a duration such as "20ms" logs only slower
+// ones.
+var QueryLogger = queryLoggerFromEnv()
+
+func queryLoggerFromEnv() querylog.Logger {
+	setting := os.Getenv("QUERY_LOG")
+	if setting == "" {
+		return nil
+	}
+
+	level := slog.LevelInfo
+	options := querylog.SlogOptions{Level: slog.LevelInfo}
+	if setting != "all" {
+		threshold, err := time.ParseDuration(setting)
+		if err != nil {
+			panic(fmt.Errorf("invalid QUERY_LOG %q: %v", setting, err))
+		}
+		level, options.SlowThreshold = slog.LevelWarn, threshold
+	}
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	return querylog.NewSlog(slog.New(handler), options)
+}
+
+// Tracer records the spans of repository calls and their statements in the
+// JSON Lines file named by TRACE_FILE. It is nil when TRACE_FILE is unset.
+var Tracer = tracerFromEnv()
+
+func tracerFromEnv() *trace.Tracer {
+	path := os.Getenv("TRACE_FILE")
+	if path == "" {
+		return nil
+	}
+	exporter, err := trace.NewJSONFile(path)
+	if err != nil {
+		panic(fmt.Errorf("invalid TRACE_FILE %q: %v", path, err))
+	}
+	trace.SetExporter(exporter)
+	return trace.Default
+}
+
+// statementLoggers combines loggers with Metrics, RoundTrips, Tracer and
+// Explain, when enabled. It is nil when none is, and the databases are then opened without
+// the querylog wrapper.
+func statementLoggers(loggers ...querylog.Logger) querylog.Logger {
+	if Metrics != nil {
+		loggers = append(loggers, Metrics)
+	}
+	if CountRoundTrips {
+		loggers = append(loggers, RoundTrips)
+	}
+	if Tracer != nil {
+		loggers = append(loggers, Tracer)
+	}
+	if Explain != nil {
+		loggers = append(loggers, Explain)
+	}
+	return querylog.Multi(loggers...)
+}
+
+// RoundTrips counts the statements of every database opened by SetupDB,
+// SetupGorm and SetupPgx, a pgx batch counting as one. See CountQueries and
+// RequireQueryBudget.
+var RoundTrips = &querylog.Counter{}
+
+// CountRoundTrips is false when QUERY_COUNT is "off". RoundTrips then counts
+// nothing and no queries/op is reported, so that, with no other logger
+// enabled, the approaches run on their plain drivers as in the baseline.
+var CountRoundTrips = os.Getenv("QUERY_COUNT") != "off"
+
 func SetupDB() *sql.DB {
-	psqlInfo := "host=localhost port=5432 user=my_user password=my@Pass%1234 dbname=my_database sslmode=disable"
-	db, err := sql.Open("postgres", psqlInfo)
+	var db *sql.DB
+	var err error
+	if logger := statementLoggers(QueryLogger); logger != nil {
+		db, err = querylog.Open("postgres", PsqlInfo, logger)
+	} else {
+		db, err = sql.Open("postgres", PsqlInfo)
+	}
+	if err != nil {
+		panic(err)
+	}
+	configurePool(db)
+	registerPool("postgres", db)
+	return db
+}
+
+// SetupGorm opens GORM with its own driver. Its statements are counted and
+// logged through a GORM logger rather than a wrapped driver, which does not
+// see the BEGIN and COMMIT of GORM's transactions.
+func SetupGorm() *gorm.DB {
+	config := &gorm.Config{}
+	if logger := statementLoggers(QueryLogger); logger != nil {
+		config.Logger = querylog.Gorm(logger)
+	}
+	db, err := gorm.Open(postgres.Open(PsqlInfo), config)
 	if err != nil {
 		panic(err)
 	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic(err)
+	}
+	configurePool(sqlDB)
+	registerPool("gorm", sqlDB)
 	return db
 }
 
+// SetupPgx opens a native pgx pool, whose round trips are counted like those
+// of the other approaches. POOL_SIZE and POOL_LIFETIME apply to it as they do
+// to database/sql pools; pgx keeps no separate idle limit.
+func SetupPgx() *pgxpool.Pool {
+	config, err := pgxpool.ParseConfig(PsqlInfo)
+	if err != nil {
+		panic(err)
+	}
+	if logger := statementLoggers(QueryLogger); logger != nil {
+		config.ConnConfig.Tracer = querylog.Pgx(logger)
+	}
+	pool := poolConfig()
+	if pool.MaxOpen > 0 {
+		config.MaxConns = int32(pool.MaxOpen)
+	}
+	if pool.MaxLifetime > 0 {
+		config.MaxConnLifetime = pool.MaxLifetime
+	}
+
+	pgxPool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		panic(err)
+	}
+	return pgxPool
+}
+
+// PoolConfig are the connection pool settings of the databases opened by
+// SetupDB, SetupGorm and SetupPgx. Zero fields keep the driver defaults.
+type PoolConfig struct {
+	MaxOpen     int           // POOL_SIZE
+	MaxIdle     int           // POOL_IDLE, POOL_SIZE when unset
+	MaxLifetime time.Duration // POOL_LIFETIME, such as 30s
+}
+
+// configurePool applies the pool settings to db, GORM's included.
+func configurePool(db *sql.DB) {
+	pool := poolConfig()
+	if pool.MaxOpen > 0 {
+		db.SetMaxOpenConns(pool.MaxOpen)
+	}
+	if pool.MaxIdle > 0 {
+		db.SetMaxIdleConns(pool.MaxIdle)
+	}
+	if pool.MaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.MaxLifetime)
+	}
+}
+
+// poolConfig reads POOL_SIZE, POOL_IDLE and POOL_LIFETIME.
+func poolConfig() PoolConfig {
+	pool := PoolConfig{MaxOpen: positiveEnv("POOL_SIZE"), MaxIdle: positiveEnv("POOL_IDLE")}
+	if pool.MaxIdle == 0 {
+		pool.MaxIdle = pool.MaxOpen
+	}
+	if setting := os.Getenv("POOL_LIFETIME"); setting != "" {
+		lifetime, err := time.ParseDuration(setting)
+		if err != nil || lifetime <= 0 {
+			panic(fmt.Errorf("invalid POOL_LIFETIME %q", setting))
+		}
+		pool.MaxLifetime = lifetime
+	}
+	return pool
+}
+
+// positiveEnv reads a positive integer variable, 0 when it is unset.
+func positiveEnv(name string) int {
+	setting := os.Getenv(name)
+	if setting == "" {
+		return 0
+	}
+	value, err := strconv.Atoi(setting)
+	if err != nil || value < 1 {
+		panic(fmt.Errorf("invalid %s %q", name, setting))
+	}
+	return value
+}
+
 func ClearAllProjectsAndResources(db *sql.DB) error {
 	queries := []string{
 		"DELETE FROM PROJECTS;",
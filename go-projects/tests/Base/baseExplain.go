@@ -0,0 +1,46 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"m/utils/explain"
+	"os"
+	"testing"
+)
+
+// Explain captures the plan of every distinct statement issued through
+// SetupDB, SetupGorm and SetupPgx into the JSON file named by EXPLAIN_FILE.
+// The plans are taken after each benchmark, with its timer stopped, but the
+// capture still loads the database: keep timings from such runs out of
+// comparisons. It is nil when EXPLAIN_FILE is unset.
+var Explain = explainFromEnv()
+
+func explainFromEnv() *explain.Capturer {
+	if os.Getenv("EXPLAIN_FILE") == "" {
+		return nil
+	}
+	db, err := sql.Open("postgres", PsqlInfo)
+	if err != nil {
+		panic(fmt.Errorf("failed to open the EXPLAIN connection: %v", err))
+	}
+	return explain.New(db, nil)
+}
+
+func scopeExplain(b *testing.B) {
+	if Explain != nil {
+		Explain.SetScope(approach, operation(b))
+	}
+}
+
+// flushExplain explains the statements of the benchmark and rewrites
+// EXPLAIN_FILE.
+func flushExplain(b *testing.B) {
+	if Explain == nil {
+		return
+	}
+	b.StopTimer()
+	Explain.Flush()
+	if err := Explain.DumpJSON(os.Getenv("EXPLAIN_FILE")); err != nil {
+		b.Errorf("Failed to write EXPLAIN_FILE: %v", err)
+	}
+}
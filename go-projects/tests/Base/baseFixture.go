@@ -0,0 +1,83 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+// Fixture is the database state a benchmark starts from. Prepare sets it up
+// before the timer starts, so no benchmark depends on what an earlier one
+// left behind.
+type Fixture int
+
+const (
+	// Empty has no rows, for inserting resources.
+	Empty Fixture = iota
+	// WithResources has the resources of the input data, for inserting
+	// projects that link to them.
+	WithResources
+	// WithProjects has the resources and the projects of the input data, for
+	// reading, updating and deleting them.
+	WithProjects
+)
+
+func (f Fixture) String() string {
+	switch f {
+	case Empty:
+		return "Empty"
+	case WithResources:
+		return "WithResources"
+	case WithProjects:
+		return "WithProjects"
+	}
+	return fmt.Sprintf("Fixture(%d)", int(f))
+}
+
+// Prepare empties the tables with Truncate and stores the state of fixture
+// through approach. Round trips are counted, so call it before CountQueries
+// or between QueryMeter.Stop and Start.
+func Prepare(tb testing.TB, approach Approach, data TestInput, fixture Fixture) {
+	tb.Helper()
+	if err := Truncate(approach.DB()); err != nil {
+		tb.Fatalf("Error cleaning database: %s", err)
+	}
+	if fixture >= WithResources {
+		for i := range data.Resources {
+			if err := approach.InsertResource(i); err != nil {
+				tb.Fatalf("Failed to insert resource: %v", err)
+			}
+		}
+	}
+	if fixture >= WithProjects {
+		StoreProjects(tb, approach, data)
+	}
+}
+
+// StoreProjects replaces the projects in the database with those of data,
+// leaving the resources as they are.
+func StoreProjects(tb testing.TB, approach Approach, data TestInput) {
+	tb.Helper()
+	if err := TruncateProjects(approach.DB()); err != nil {
+		tb.Fatalf("Error cleaning projects: %s", err)
+	}
+	for i := range data.Projects {
+		if err := approach.InsertProject(i); err != nil {
+			tb.Fatalf("Failed to insert project: %v", err)
+		}
+	}
+}
+
+// Truncate empties every table. Unlike the DELETE of
+// ClearAllProjectsAndResources it takes the same time however many rows the
+// tables hold, and leaves no dead rows to be vacuumed during a benchmark.
+func Truncate(db *sql.DB) error {
+	_, err := db.Exec("TRUNCATE TASK_RESOURCE, TASKS, PROJECTS, RESOURCES;")
+	return err
+}
+
+// TruncateProjects empties the projects, with their tasks and links.
+func TruncateProjects(db *sql.DB) error {
+	_, err := db.Exec("TRUNCATE TASK_RESOURCE, TASKS, PROJECTS;")
+	return err
+}
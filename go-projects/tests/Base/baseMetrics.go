@@ -0,0 +1,93 @@
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"m/utils/metrics"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Metrics collects the statements of the databases opened by SetupDB, SetupGorm
+// and SetupPgx, and the figures of the database/sql pools among them, labelled
+// by approach and benchmark. METRICS_ADDR serves them at /metrics during the
+// run, and METRICS_FILE receives a JSON dump after each benchmark. Metrics is
+// nil when neither is set.
+var Metrics = metricsFromEnv()
+
+// approach is the directory of the package under test, such as "GORM".
+var approach = currentApproach()
+
+func metricsFromEnv() *metrics.Collector {
+	addr := os.Getenv("METRICS_ADDR")
+	if addr == "" && os.Getenv("METRICS_FILE") == "" {
+		return nil
+	}
+	collector := metrics.NewCollector(nil)
+	if addr == "" {
+		return collector
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", collector)
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			fmt.Fprintf(os.Stderr, "metrics server on %s stopped: %v\n", addr, err)
+		}
+	}()
+	return collector
+}
+
+func currentApproach() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(dir)
+}
+
+func registerPool(driverName string, db *sql.DB) {
+	if Metrics == nil {
+		return
+	}
+	Metrics.RegisterPool(approach+"/"+driverName, db)
+}
+
+// scopeMetrics labels the following statements with the benchmark name. The
+// scope is held by the collector, not by the statements' context, so it
+// assumes one benchmark runs at a time, as go test does without t.Parallel.
+func scopeMetrics(b *testing.B) {
+	if Metrics == nil {
+		return
+	}
+	Metrics.SetScope(metrics.Scope{Approach: approach, Operation: operation(b)})
+}
+
+// operation is the benchmark name without the Benchmark prefix and, in the
+// shared suite, without the sub-benchmark of the approach:
+// "BenchmarkReadProject/GORM" is the ReadProject operation of GORM.
+func operation(b *testing.B) string {
+	segments := strings.Split(strings.TrimPrefix(b.Name(), "Benchmark"), "/")
+	for i := 1; i < len(segments); i++ {
+		if segments[i] == approach {
+			segments = append(segments[:i], segments[i+1:]...)
+			break
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// dumpMetrics writes the collected metrics to METRICS_FILE, when set.
+func dumpMetrics(tb testing.TB) {
+	path := os.Getenv("METRICS_FILE")
+	if path == "" || Metrics == nil {
+		return
+	}
+	if err := Metrics.DumpJSON(path); err != nil {
+		tb.Errorf("Failed to write METRICS_FILE %q: %v", path, err)
+	}
+}
@@ -0,0 +1,87 @@
+package base
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ParallelMeter reports a b.RunParallel benchmark: the round trips as
+// queries/op, the throughput as ops/s and, for approaches that are
+// PoolWaiters, the waits for a free connection as pool-waits/op and
+// pool-wait-ns/op. Pool waits grow as the goroutines, set by -cpu and
+// b.SetParallelism, outnumber the connections allowed by POOL_SIZE.
+type ParallelMeter struct {
+	b         *testing.B
+	queries   *QueryMeter
+	waiter    PoolWaiter
+	waits     int64
+	waited    time.Duration
+	startTime time.Time
+}
+
+// MeasureParallel starts measuring approach in b. Call it right before
+// b.ResetTimer and b.RunParallel, and Report right after.
+func MeasureParallel(b *testing.B, approach Approach) *ParallelMeter {
+	meter := &ParallelMeter{b: b, queries: CountQueries(b)}
+	if waiter, ok := approach.(PoolWaiter); ok {
+		meter.waiter = waiter
+		meter.waits, meter.waited = waiter.PoolWait()
+	}
+	meter.startTime = time.Now()
+	return meter
+}
+
+// Report reports the metrics of the benchmark, besides those of QueryMeter.
+func (m *ParallelMeter) Report() {
+	elapsed := time.Since(m.startTime)
+	n := float64(m.b.N)
+	m.b.ReportMetric(n/elapsed.Seconds(), "ops/s")
+	if m.waiter != nil {
+		waits, waited := m.waiter.PoolWait()
+		m.b.ReportMetric(float64(waits-m.waits)/n, "pool-waits/op")
+		m.b.ReportMetric(float64(waited-m.waited)/n, "pool-wait-ns/op")
+	}
+	m.queries.Report()
+}
+
+// Cycle hands out 0, 1, ..., n-1, 0, 1, ... to the goroutines of a parallel
+// benchmark, so that they spread over the projects of the input data.
+type Cycle struct {
+	n    int64
+	next atomic.Int64
+}
+
+// NewCycle returns a Cycle over n indexes.
+func NewCycle(n int) *Cycle {
+	return &Cycle{n: int64(n)}
+}
+
+// Next returns the next index.
+func (c *Cycle) Next() int {
+	return int((c.next.Add(1) - 1) % c.n)
+}
+
+// Claims hands out indexes that are not in use by another goroutine, for
+// operations that cannot run twice at once on the same project, such as
+// inserting it.
+type Claims chan int
+
+// NewClaims returns Claims over n indexes.
+func NewClaims(n int) Claims {
+	claims := make(Claims, n)
+	for i := 0; i < n; i++ {
+		claims <- i
+	}
+	return claims
+}
+
+// Claim waits for a free index and takes it.
+func (c Claims) Claim() int {
+	return <-c
+}
+
+// Release frees an index taken by Claim.
+func (c Claims) Release(i int) {
+	c <- i
+}
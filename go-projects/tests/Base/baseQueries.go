@@ -0,0 +1,75 @@
+package base
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// QueryMeter reports the round trips of a benchmark as queries/op, next to
+// ns/op. Stop and Start exclude setup work, like b.StopTimer and b.StartTimer.
+type QueryMeter struct {
+	b       *testing.B
+	start   int64
+	counted int64
+	running bool
+}
+
+// CountQueries starts counting the round trips of b, and labels them with its
+// name in Metrics and Explain. Call it right before b.ResetTimer.
+func CountQueries(b *testing.B) *QueryMeter {
+	scopeMetrics(b)
+	scopeExplain(b)
+	return &QueryMeter{b: b, start: RoundTrips.Count(), running: true}
+}
+
+func (m *QueryMeter) Stop() {
+	if m.running {
+		m.counted += RoundTrips.Count() - m.start
+		m.running = false
+	}
+}
+
+func (m *QueryMeter) Start() {
+	if !m.running {
+		m.start = RoundTrips.Count()
+		m.running = true
+	}
+}
+
+// Report stops counting, reports the average per benchmark iteration, dumps
+// Metrics to METRICS_FILE and the plans of Explain to EXPLAIN_FILE.
+func (m *QueryMeter) Report() {
+	m.Stop()
+	if CountRoundTrips {
+		m.b.ReportMetric(float64(m.counted)/float64(m.b.N), "queries/op")
+	}
+	dumpMetrics(m.b)
+	flushExplain(m.b)
+}
+
+// RequireQueryBudget runs op and fails tb when it returns an error or issues
+// more than budget round trips through SetupDB, SetupGorm or SetupPgx
+// databases. The budget is not checked when QUERY_COUNT is off.
+func RequireQueryBudget(tb testing.TB, name string, budget int64, op func() error) {
+	tb.Helper()
+	queries, err := RoundTrips.Measure(op)
+	if err != nil {
+		tb.Fatalf("%s failed: %v", name, err)
+	}
+	if CountRoundTrips && queries > budget {
+		tb.Errorf("%s issued %d queries, over its budget of %d", name, queries, budget)
+	}
+}
+
+// SkipWithoutDB skips tb when the test database is not running.
+func SkipWithoutDB(tb testing.TB) {
+	tb.Helper()
+	db, err := sql.Open("postgres", PsqlInfo)
+	if err == nil {
+		err = db.Ping()
+		db.Close()
+	}
+	if err != nil {
+		tb.Skipf("PostgreSQL is not available: %v", err)
+	}
+}
@@ -6,8 +6,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"m/utils/diff"
 	"os"
-	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -51,8 +52,17 @@ type TestInput struct {
 	Projects  []BaseProject  `json:"projects"`
 }
 
+// inputPath is the file named by DATASET, or tests/input.json. See
+// datasetSpec for generated datasets.
+func inputPath() string {
+	if path := os.Getenv("DATASET"); path != "" {
+		return path
+	}
+	return "../input.json"
+}
+
 func openInputJson() ([]byte, error) {
-	jsonFile, err := os.Open("../input.json")
+	jsonFile, err := os.Open(inputPath())
 	if err != nil {
 		log.Fatalf("Error opening JSON file: %s", err)
 	}
@@ -62,22 +72,39 @@ func openInputJson() ([]byte, error) {
 	return byteValue, err
 }
 
-func GetInputData(b *testing.B) TestInput {
+func GetInputData(tb testing.TB) TestInput {
+	if spec, generated := datasetSpec(); generated {
+		data, err := generateInput(spec)
+		if err != nil {
+			tb.Fatalf("Error generating test data: %s", err)
+		}
+		return data
+	}
+
 	byteValue, err := openInputJson()
 	if err != nil {
-		b.Fatalf("Error reading test JSON file: %s", err)
+		tb.Fatalf("Error reading test JSON file: %s", err)
 	}
 
 	var data TestInput
 	err = json.Unmarshal(byteValue, &data)
 	if err != nil {
-		b.Fatalf("Error deserializing test JSON file: %s", err)
+		tb.Fatalf("Error deserializing test JSON file: %s", err)
 	}
 
 	return data
 }
 
 func OpenInputData() (*TestInput, error) {
+	if spec, generated := datasetSpec(); generated {
+		data, err := generateInput(spec)
+		if err != nil {
+			log.Fatalf("Error generating test data: %s", err)
+			return nil, err
+		}
+		return &data, nil
+	}
+
 	byteValue, err := openInputJson()
 	if err != nil {
 		log.Fatalf("Error reading test JSON file: %s", err)
@@ -94,6 +121,21 @@ func OpenInputData() (*TestInput, error) {
 	return &data, nil
 }
 
+// datasetSpec returns DATASET when it is a spec to generate, such as
+// "gen:scale=4,seed=7", instead of a file.
+func datasetSpec() (string, bool) {
+	spec := os.Getenv("DATASET")
+	return spec, strings.HasPrefix(spec, DatasetPrefix)
+}
+
+func generateInput(text string) (TestInput, error) {
+	spec, err := ParseDatasetSpec(text)
+	if err != nil {
+		return TestInput{}, err
+	}
+	return GenerateDataset(spec), nil
+}
+
 func Cast[T any](input any) (output T, err error) {
 	// Serialize the input to JSON
 	jsonData, err := json.Marshal(input)
@@ -110,33 +152,28 @@ func Cast[T any](input any) (output T, err error) {
 	return output, nil
 }
 
-func CompareObjectsAsJSON(obj1, obj2 interface{}) error {
-	// Convert both objects to JSON
-	json1, err := json.Marshal(obj1)
-	if err != nil {
-		return fmt.Errorf("erro ao converter obj1 para JSON: %v", err)
-	}
+// maxReported caps the differences listed by CompareObjects.
+const maxReported = 10
 
-	json2, err := json.Marshal(obj2)
+// CompareObjects compares the project graphs, or any objects, expected and
+// actual through their JSON names with utils/diff, and lists where they
+// differ, one path per line.
+func CompareObjects(expected, actual interface{}) error {
+	differences, err := diff.Compare(expected, actual)
 	if err != nil {
-		return fmt.Errorf("erro ao converter obj2 para JSON: %v", err)
+		return err
 	}
-
-	// Count the frequency of each byte in both json arrays
-	freq1 := make(map[byte]int)
-	freq2 := make(map[byte]int)
-
-	for _, b := range json1 {
-		freq1[b]++
-	}
-	for _, b := range json2 {
-		freq2[b]++
+	if len(differences) == 0 {
+		return nil
 	}
 
-	// Check if frequencies are the same
-	if !reflect.DeepEqual(freq1, freq2) {
-		return errors.New("arrays have different byte frequencies and cannot be reordered")
+	lines := make([]string, 0, maxReported+1)
+	for i, difference := range differences {
+		if i == maxReported {
+			lines = append(lines, fmt.Sprintf("and %d more", len(differences)-maxReported))
+			break
+		}
+		lines = append(lines, difference.String())
 	}
-
-	return nil
+	return errors.New(strings.Join(lines, "\n"))
 }
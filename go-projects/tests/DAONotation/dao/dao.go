@@ -1,22 +1,42 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
+	"m/utils/trace"
 	"reflect"
 	"strconv"
 	"strings"
 )
 
 type DAO struct {
-	Db *sql.DB
+	Db  *sql.DB
+	ctx context.Context
 }
 
 func NewDAO(db *sql.DB) DAO {
 	return DAO{Db: db}
 }
 
+// WithContext returns a DAO whose statements run with ctx, and whose spans
+// are children of the span ctx carries.
+func (d DAO) WithContext(ctx context.Context) DAO {
+	d.ctx = ctx
+	return d
+}
+
+func (d DAO) context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
 // Create performs insert considering auto-increment ID via database.
 func (d DAO) Create(tableName string, entity interface{}) (int, error) {
+	ctx, span := trace.Start(d.context(), "DAO.Create", "table", tableName)
+	defer span.End()
+
 	// Use reflection to iterate over entity fields
 	val := reflect.ValueOf(entity).Elem()
 	typeOfT := val.Type()
@@ -43,7 +63,7 @@ func (d DAO) Create(tableName string, entity interface{}) (int, error) {
 		") VALUES (" + strings.Join(placeholders, ", ") + ") RETURNING ID"
 
 	var newID int
-	err := d.Db.QueryRow(query, fieldValues...).Scan(&newID)
+	err := d.Db.QueryRowContext(ctx, query, fieldValues...).Scan(&newID)
 	if err != nil {
 		return -1, err
 	}
@@ -56,6 +76,9 @@ func (d DAO) Create(tableName string, entity interface{}) (int, error) {
 }
 
 func (d DAO) CreateChild(tableName string, entity interface{}, foreignKey string, foreignKeyValue int) (int, error) {
+	ctx, span := trace.Start(d.context(), "DAO.CreateChild", "table", tableName)
+	defer span.End()
+
 	// Use reflection to iterate over entity fields
 	val := reflect.ValueOf(entity).Elem()
 	typeOfT := val.Type()
@@ -88,7 +111,7 @@ func (d DAO) CreateChild(tableName string, entity interface{}, foreignKey string
 		") RETURNING ID"
 
 	var newID int
-	err := d.Db.QueryRow(query, fieldValues...).Scan(&newID)
+	err := d.Db.QueryRowContext(ctx, query, fieldValues...).Scan(&newID)
 	if err != nil {
 		return -1, err
 	}
@@ -101,12 +124,15 @@ func (d DAO) CreateChild(tableName string, entity interface{}, foreignKey string
 }
 
 func (d DAO) CreateWithLinkSingleSide(existingParentId int, childTable string, linkTable string, childId int, parentForeignKey string, childForeignKey string) (int, error) {
+	ctx, span := trace.Start(d.context(), "DAO.CreateWithLinkSingleSide", "table", linkTable)
+	defer span.End()
+
 	// Insert into the link table (e.g., OBJECT_ITEM_LINK) using the existing parent object ID
 	linkQuery := "INSERT INTO " + linkTable +
 		" (" + parentForeignKey + ", " +
 		childForeignKey + ") VALUES ($1, $2)"
 
-	_, err := d.Db.Exec(linkQuery, existingParentId, childId)
+	_, err := d.Db.ExecContext(ctx, linkQuery, existingParentId, childId)
 	if err != nil {
 		return childId, err
 	}
@@ -116,6 +142,9 @@ func (d DAO) CreateWithLinkSingleSide(existingParentId int, childTable string, l
 
 // Read fetches an entity by ID and fills the passed struct with the found data.
 func (d DAO) Read(tableName string, id interface{}, entity interface{}) error {
+	ctx, span := trace.Start(d.context(), "DAO.Read", "table", tableName)
+	defer span.End()
+
 	val := reflect.ValueOf(entity).Elem()
 	typeOfEntity := val.Type()
 
@@ -138,7 +167,7 @@ func (d DAO) Read(tableName string, id interface{}, entity interface{}) error {
 	query := "SELECT " + cols + " FROM " + tableName + " WHERE id = $1"
 
 	// Execute SQL query
-	row := d.Db.QueryRow(query, id)
+	row := d.Db.QueryRowContext(ctx, query, id)
 	if err := row.Scan(scanTargets...); err != nil {
 		return err
 	}
@@ -148,6 +177,9 @@ func (d DAO) Read(tableName string, id interface{}, entity interface{}) error {
 
 // Update updates any struct in the database.
 func (d DAO) Update(tableName string, entity interface{}) error {
+	ctx, span := trace.Start(d.context(), "DAO.Update", "table", tableName)
+	defer span.End()
+
 	val := reflect.ValueOf(entity).Elem()
 	typeOfEntity := val.Type()
 
@@ -184,7 +216,7 @@ func (d DAO) Update(tableName string, entity interface{}) error {
 		" = $" + strconv.Itoa(len(fieldValues))
 
 	// Execute SQL query
-	_, err := d.Db.Exec(query, fieldValues...)
+	_, err := d.Db.ExecContext(ctx, query, fieldValues...)
 	if err != nil {
 		return err
 	}
@@ -194,11 +226,14 @@ func (d DAO) Update(tableName string, entity interface{}) error {
 
 // Delete removes an entity by ID from the specified table.
 func (d DAO) Delete(tableName string, id interface{}) error {
+	ctx, span := trace.Start(d.context(), "DAO.Delete", "table", tableName)
+	defer span.End()
+
 	// Build SQL query string
 	query := "DELETE FROM " + tableName + " WHERE id = $1"
 
 	// Execute SQL query
-	result, err := d.Db.Exec(query, id)
+	result, err := d.Db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -209,8 +244,12 @@ func (d DAO) Delete(tableName string, id interface{}) error {
 }
 
 // ReadMultiple fetches multiple entities based on an SQL condition and arguments.
-// The function accepts an empty struct as a model for the results.
-func (d DAO) ReadMultiple(tableName string, condition string, args []interface{}, model interface{}) ([]interface{}, error) {
+// The function accepts an empty struct as a model for the results and an
+// optional ORDER BY clause.
+func (d DAO) ReadMultiple(tableName string, condition string, args []interface{}, model interface{}, orderBy ...string) ([]interface{}, error) {
+	ctx, span := trace.Start(d.context(), "DAO.ReadMultiple", "table", tableName)
+	defer span.End()
+
 	sliceType := reflect.SliceOf(reflect.TypeOf(model))
 	resultsSlice := reflect.MakeSlice(sliceType, 0, 0)
 
@@ -232,8 +271,11 @@ func (d DAO) ReadMultiple(tableName string, condition string, args []interface{}
 	query := "SELECT " + strings.Join(columnNames, ", ") +
 		" FROM " + tableName +
 		" WHERE " + condition
+	if len(orderBy) > 0 {
+		query += " ORDER BY " + strings.Join(orderBy, ", ")
+	}
 
-	rows, err := d.Db.Query(query, args...)
+	rows, err := d.Db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
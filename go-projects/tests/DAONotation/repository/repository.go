@@ -1,14 +1,20 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"m/tests/DAONotation/dao"
 	"m/tests/DAONotation/entities"
+	"m/utils/orderby"
+	"m/utils/trace"
 )
 
 // InsertResource inserts a single resource into the RESOURCES table.
 func InsertResource(db *sql.DB, resource entities.Resource) (int, error) {
-	daoResource := dao.NewDAO(db)
+	ctx, span := trace.Start(context.Background(), "DAONotation.InsertResource", "resource.id", resource.ID)
+	defer span.End()
+
+	daoResource := dao.NewDAO(db).WithContext(ctx)
 	resourceId, err := daoResource.Create("RESOURCES", &resource)
 	if err != nil {
 		return -1, err
@@ -18,7 +24,10 @@ func InsertResource(db *sql.DB, resource entities.Resource) (int, error) {
 
 // Inserts a project and its associated tasks and resources.
 func InsertProject(db *sql.DB, project entities.Project) (int, error) {
-	daoProject := dao.NewDAO(db)
+	ctx, span := trace.Start(context.Background(), "DAONotation.InsertProject", "project.id", project.ID)
+	defer span.End()
+
+	daoProject := dao.NewDAO(db).WithContext(ctx)
 	projectId, err := daoProject.Create("PROJECTS", &project)
 	if err != nil {
 		return projectId, err
@@ -41,7 +50,10 @@ func InsertProject(db *sql.DB, project entities.Project) (int, error) {
 
 // Updates an existing project.
 func UpdateProject(db *sql.DB, project *entities.Project) error {
-	daoProject := dao.NewDAO(db)
+	ctx, span := trace.Start(context.Background(), "DAONotation.UpdateProject", "project.id", project.ID)
+	defer span.End()
+
+	daoProject := dao.NewDAO(db).WithContext(ctx)
 	err := daoProject.Update("PROJECTS", project)
 	if err != nil {
 		return err
@@ -57,16 +69,33 @@ func UpdateProject(db *sql.DB, project *entities.Project) error {
 
 // Deletes a project by ID.
 func DeleteProject(db *sql.DB, projectID int) error {
-	daoProject := dao.NewDAO(db)
+	ctx, span := trace.Start(context.Background(), "DAONotation.DeleteProject", "project.id", projectID)
+	defer span.End()
+
+	daoProject := dao.NewDAO(db).WithContext(ctx)
 	return daoProject.Delete("PROJECTS", projectID)
 }
 
 // Reads a project, along with its associated tasks and resources, by project ID.
-func ReadProject(db *sql.DB, projectID int) (*entities.Project, error) {
+// Tasks and resources are sorted by key or by the given order.
+func ReadProject(db *sql.DB, projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	ctx, span := trace.Start(context.Background(), "DAONotation.ReadProject", "project.id", projectID)
+	defer span.End()
+
 	project := &entities.Project{}
-	daoProject := dao.NewDAO(db)
+	daoProject := dao.NewDAO(db).WithContext(ctx)
+
+	sortOrder := orderby.Resolve(order)
+	taskOrder, err := sortOrder.TaskClause("")
+	if err != nil {
+		return project, err
+	}
+	resourceOrder, err := sortOrder.ResourceClause("")
+	if err != nil {
+		return project, err
+	}
 
-	err := daoProject.Read("PROJECTS", projectID, project)
+	err = daoProject.Read("PROJECTS", projectID, project)
 	if err != nil {
 		return project, err
 	}
@@ -76,7 +105,7 @@ func ReadProject(db *sql.DB, projectID int) (*entities.Project, error) {
 	modelTask := &entities.Task{}
 
 	// Fetch tasks associated with the project
-	tasks, err := daoProject.ReadMultiple("TASKS", condition, args, modelTask)
+	tasks, err := daoProject.ReadMultiple("TASKS", condition, args, modelTask, taskOrder)
 	if err != nil {
 		return project, err
 	}
@@ -88,7 +117,7 @@ func ReadProject(db *sql.DB, projectID int) (*entities.Project, error) {
 		modelResource := &entities.Resource{}
 
 		// Fetch resources associated with each task
-		resources, err := daoProject.ReadMultiple("TASK_RESOURCE_VIEW", condition, args, modelResource)
+		resources, err := daoProject.ReadMultiple("TASK_RESOURCE_VIEW", condition, args, modelResource, resourceOrder)
 		if err != nil {
 			return project, err
 		}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"m/tests/DirectStruct/entities"
+	"m/utils/orderby"
+	"m/utils/trace"
+)
+
+// ReadProjectJSON reads a project by ID like ReadProject, but PostgreSQL builds
+// the whole project document with json_build_object and json_agg, so a single
+// row comes back, without the project and task columns repeated for every
+// task-resource pair, and is decoded straight into the entities. The document
+// comes from the READ_PROJECT function of database/procedures.sql, which the
+// StoredProcedure approach also reads with.
+func ReadProjectJSON(db *sql.DB, projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	ctx, span := trace.Start(context.Background(), "DirectStruct.ReadProjectJSON", "project.id", projectID)
+	defer span.End()
+
+	resolved := orderby.Resolve(order)
+	taskOrder, err := resolved.TaskClause("t")
+	if err != nil {
+		return nil, err
+	}
+	resourceOrder, err := resolved.ResourceClause("r")
+	if err != nil {
+		return nil, err
+	}
+
+	var document []byte
+	err = db.QueryRowContext(ctx, `SELECT READ_PROJECT($1, $2, $3)`, projectID, taskOrder, resourceOrder).Scan(&document)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	var project entities.Project
+	if err := json.Unmarshal(document, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
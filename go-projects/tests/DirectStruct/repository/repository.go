@@ -1,20 +1,26 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"m/tests/DirectStruct/entities"
-	"time"
+	"m/utils/assembler"
+	"m/utils/orderby"
+	"m/utils/trace"
 )
 
 // InsertResource inserts a single resource into the RESOURCES table.
 func InsertResource(db *sql.DB, resource entities.Resource) (int, error) {
+	ctx, span := trace.Start(context.Background(), "DirectStruct.InsertResource", "resource.id", resource.ID)
+	defer span.End()
+
 	query := `
 		INSERT INTO RESOURCES (ID, TYPE, NAME, DAILY_COST, STATUS, SUPPLIER, QUANTITY, ACQUISITION_DATE)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING ID
 	`
 	var resourceID int
-	err := db.QueryRow(query, resource.ID, resource.Type, resource.Name, resource.DailyCost, resource.Status, resource.Supplier, resource.Quantity, resource.AcquisitionDate).Scan(&resourceID)
+	err := db.QueryRowContext(ctx, query, resource.ID, resource.Type, resource.Name, resource.DailyCost, resource.Status, resource.Supplier, resource.Quantity, resource.AcquisitionDate).Scan(&resourceID)
 	if err != nil {
 		return 0, err
 	}
@@ -24,6 +30,9 @@ func InsertResource(db *sql.DB, resource entities.Resource) (int, error) {
 
 // InsertProject inserts a project along with its tasks and linked resources.
 func InsertProject(db *sql.DB, project entities.Project) (int, error) {
+	ctx, span := trace.Start(context.Background(), "DirectStruct.InsertProject", "project.id", project.ID)
+	defer span.End()
+
 	// Insert the main project
 	query := `
 		INSERT INTO PROJECTS (ID, NAME, MANAGER, START_DATE, END_DATE, BUDGET, DESCRIPTION)
@@ -31,7 +40,7 @@ func InsertProject(db *sql.DB, project entities.Project) (int, error) {
 		RETURNING ID
 	`
 	var projectID int
-	err := db.QueryRow(query, project.ID, project.Name, project.Manager, project.StartDate, project.EndDate, project.Budget, project.Description).Scan(&projectID)
+	err := db.QueryRowContext(ctx, query, project.ID, project.Name, project.Manager, project.StartDate, project.EndDate, project.Budget, project.Description).Scan(&projectID)
 	if err != nil {
 		return 0, err
 	}
@@ -44,7 +53,7 @@ func InsertProject(db *sql.DB, project entities.Project) (int, error) {
 			RETURNING ID
 		`
 		var taskID int
-		err := db.QueryRow(taskQuery, task.ID, task.Name, task.Responsible, task.Deadline, task.Status, task.Priority, task.EstimatedTime, projectID, task.Description).Scan(&taskID)
+		err := db.QueryRowContext(ctx, taskQuery, task.ID, task.Name, task.Responsible, task.Deadline, task.Status, task.Priority, task.EstimatedTime, projectID, task.Description).Scan(&taskID)
 		if err != nil {
 			return projectID, err
 		}
@@ -55,7 +64,7 @@ func InsertProject(db *sql.DB, project entities.Project) (int, error) {
 				INSERT INTO TASK_RESOURCE (TASK_ID, RESOURCE_ID, QUANTITY_USED)
 				VALUES ($1, $2, $3)
 			`
-			_, err := db.Exec(linkQuery, taskID, resource.ID, resource.Quantity)
+			_, err := db.ExecContext(ctx, linkQuery, taskID, resource.ID, resource.Quantity)
 			if err != nil {
 				return projectID, err
 			}
@@ -65,10 +74,30 @@ func InsertProject(db *sql.DB, project entities.Project) (int, error) {
 	return projectID, nil
 }
 
-// Reads a project by ID, including its tasks and resources.
-func ReadProject(db *sql.DB, projectID int) (*entities.Project, error) {
+// Reads a project by ID, including its tasks and resources sorted by key or by the given order.
+func ReadProject(db *sql.DB, projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	ctx, span := trace.Start(context.Background(), "DirectStruct.ReadProject", "project.id", projectID)
+	defer span.End()
+
+	project, err := readProject[entities.Project](ctx, db, projectTree, nil, projectID, order)
+	if err == nil && project == nil {
+		// A missing project reads as an empty one, as it always has.
+		project = &entities.Project{ID: projectID}
+	}
+	return project, err
+}
+
+// readProject runs the ReadProject join and assembles its rows with tree. The
+// project is nil when it does not exist.
+func readProject[P any](ctx context.Context, db *sql.DB, tree *assembler.Node, identity assembler.Identity, projectID int, order []orderby.OrderBy) (*P, error) {
+	sortOrder, err := orderby.Resolve(order).JoinClause("t", "r")
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 	SELECT 
+		p.ID, 
 		p.NAME, 
 		p.MANAGER, 
 		p.START_DATE, 
@@ -96,95 +125,72 @@ func ReadProject(db *sql.DB, projectID int) (*entities.Project, error) {
 		LEFT JOIN TASK_RESOURCE tr ON t.ID = tr.TASK_ID
 		LEFT JOIN RESOURCES r ON r.ID = tr.RESOURCE_ID
 	WHERE p.ID = $1
-	`
+	ORDER BY ` + sortOrder
 
-	rows, err := db.Query(query, projectID)
+	rows, err := db.QueryContext(ctx, query, projectID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	project := &entities.Project{ID: projectID}
-	taskMap := make(map[int]*entities.Task)
-
-	var tID, rID sql.NullInt32
-	var pName, pManager, tName, tResponsible, tStatus, tPriority, rType, rName, rStatus, rSupplier sql.NullString
-	var pStartDate, tDeadline time.Time
-	var pEndDate, rAcquisitionDate sql.NullTime
-	var pBudget, rDailyCost sql.NullFloat64
-	var tEstimatedTime, tDescription sql.NullString
-	var rQuantity sql.NullInt32
-	var pDescription sql.NullString
-
-	for rows.Next() {
-		err := rows.Scan(
-			&pName, &pManager, &pStartDate, &pEndDate, &pBudget, &pDescription,
-			&tID, &tName, &tResponsible, &tDeadline, &tStatus, &tPriority, &tEstimatedTime, &tDescription,
-			&rID, &rType, &rName, &rDailyCost, &rStatus, &rSupplier, &rQuantity, &rAcquisitionDate,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		project.Name = pName.String
-		project.Manager = pManager.String
-		project.StartDate = pStartDate
-		project.EndDate = parseTimePtr(pEndDate)
-		project.Budget = parseFloatPtr(pBudget)
-		project.Description = parseStringPtr(pDescription)
-
-		if tID.Valid {
-			task, exists := taskMap[int(tID.Int32)]
-			if !exists {
-				task = &entities.Task{
-					ID:            int(tID.Int32),
-					Name:          tName.String,
-					Responsible:   parseStringPtr(tResponsible),
-					Deadline:      tDeadline,
-					Status:        tStatus.String,
-					Priority:      parseStringPtr(tPriority),
-					EstimatedTime: parseStringPtr(tEstimatedTime),
-					Description:   parseStringPtr(tDescription),
-				}
-				taskMap[int(tID.Int32)] = task
-			}
 
-			if rID.Valid {
-				resource := entities.Resource{
-					ID:              int(rID.Int32),
-					Type:            rType.String,
-					Name:            rName.String,
-					DailyCost:       parseFloatPtr(rDailyCost),
-					Status:          rStatus.String,
-					Supplier:        parseStringPtr(rSupplier),
-					Quantity:        parseIntPtr(rQuantity),
-					AcquisitionDate: parseTimePtr(rAcquisitionDate),
-				}
-				task.Resources = append(task.Resources, resource)
-			}
-		}
+	projects, err := assembler.AssembleWith[P](rows, tree, identity)
+	if err != nil || len(projects) == 0 {
+		return nil, err
 	}
 
-	for _, task := range taskMap {
-		project.Tasks = append(project.Tasks, *task)
-	}
+	return projects[0], nil
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
+// projectTree maps the columns of the ReadProject join onto Project -> Tasks -> Resources.
+var projectTree = newProjectTree()
+
+func newProjectTree() *assembler.Node {
+	project := assembler.New(func(p *entities.Project) []interface{} {
+		return []interface{}{&p.ID, &p.Name, &p.Manager, &p.StartDate, &p.EndDate, &p.Budget, &p.Description}
+	}, 0).Table("PROJECTS")
+	task := assembler.New(func(t *entities.Task) []interface{} {
+		return []interface{}{&t.ID, &t.Name, &t.Responsible, &t.Deadline, &t.Status, &t.Priority, &t.EstimatedTime, &t.Description}
+	}, 0).Table("TASKS")
 
-	return project, nil
+	assembler.Join(project, task, func(p *entities.Project) *[]entities.Task { return &p.Tasks })
+	assembler.Join(task, newResourceNode(), func(t *entities.Task) *[]entities.Resource { return &t.Resources })
+	return project
+}
+
+// sessionProjectTree maps the same join onto SessionProject, attaching the
+// session instances of the tasks and resources.
+var sessionProjectTree = newSessionProjectTree()
+
+func newSessionProjectTree() *assembler.Node {
+	project := assembler.New(func(p *entities.SessionProject) []interface{} {
+		return []interface{}{&p.ID, &p.Name, &p.Manager, &p.StartDate, &p.EndDate, &p.Budget, &p.Description}
+	}, 0).Table("PROJECTS")
+	task := assembler.New(func(t *entities.SessionTask) []interface{} {
+		return []interface{}{&t.ID, &t.Name, &t.Responsible, &t.Deadline, &t.Status, &t.Priority, &t.EstimatedTime, &t.Description}
+	}, 0).Table("TASKS")
+
+	assembler.JoinRefs(project, task, func(p *entities.SessionProject) *[]*entities.SessionTask { return &p.Tasks })
+	assembler.JoinRefs(task, newResourceNode(), func(t *entities.SessionTask) *[]*entities.Resource { return &t.Resources })
+	return project
+}
+
+func newResourceNode() *assembler.Node {
+	return assembler.New(func(r *entities.Resource) []interface{} {
+		return []interface{}{&r.ID, &r.Type, &r.Name, &r.DailyCost, &r.Status, &r.Supplier, &r.Quantity, &r.AcquisitionDate}
+	}, 0).Table("RESOURCES")
 }
 
 // UpdateProject updates a project and its associated tasks.
 func UpdateProject(db *sql.DB, project *entities.Project) error {
+	ctx, span := trace.Start(context.Background(), "DirectStruct.UpdateProject", "project.id", project.ID)
+	defer span.End()
+
 	// Update the main project attributes
 	query := `
 		UPDATE PROJECTS
 		SET NAME = $1, MANAGER = $2, START_DATE = $3, END_DATE = $4, BUDGET = $5, DESCRIPTION = $6
 		WHERE ID = $7
 	`
-	_, err := db.Exec(query, project.Name, project.Manager, project.StartDate, project.EndDate, project.Budget, project.Description, project.ID)
+	_, err := db.ExecContext(ctx, query, project.Name, project.Manager, project.StartDate, project.EndDate, project.Budget, project.Description, project.ID)
 	if err != nil {
 		return err
 	}
@@ -202,7 +208,7 @@ func UpdateProject(db *sql.DB, project *entities.Project) error {
 				DESCRIPTION = $7
 			WHERE ID = $8 AND PROJECT_ID = $9
 		`
-		_, err := db.Exec(taskQuery, task.Name, task.Responsible, task.Deadline, task.Status, task.Priority, task.EstimatedTime, task.Description, task.ID, project.ID)
+		_, err := db.ExecContext(ctx, taskQuery, task.Name, task.Responsible, task.Deadline, task.Status, task.Priority, task.EstimatedTime, task.Description, task.ID, project.ID)
 		if err != nil {
 			return err
 		}
@@ -213,43 +219,17 @@ func UpdateProject(db *sql.DB, project *entities.Project) error {
 
 // Deletes a project by ID.
 func DeleteProject(db *sql.DB, projectID int) error {
+	ctx, span := trace.Start(context.Background(), "DirectStruct.DeleteProject", "project.id", projectID)
+	defer span.End()
+
 	query := `
 		DELETE FROM PROJECTS
 		WHERE ID = $1
 	`
-	_, err := db.Exec(query, projectID)
+	_, err := db.ExecContext(ctx, query, projectID)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
-
-func parseStringPtr(input sql.NullString) *string {
-	if input.Valid {
-		return &input.String
-	}
-	return nil
-}
-
-func parseIntPtr(input sql.NullInt32) *int {
-	if input.Valid {
-		value := int(input.Int32)
-		return &value
-	}
-	return nil
-}
-
-func parseTimePtr(input sql.NullTime) *time.Time {
-	if input.Valid {
-		return &input.Time
-	}
-	return nil
-}
-
-func parseFloatPtr(input sql.NullFloat64) *float64 {
-	if input.Valid {
-		return &input.Float64
-	}
-	return nil
-}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"m/tests/DirectStruct/entities"
+	"m/utils/assembler"
+	"m/utils/identitymap"
+	"m/utils/orderby"
+)
+
+const resourceColumns = `ID, TYPE, NAME, DAILY_COST, STATUS, SUPPLIER, QUANTITY, ACQUISITION_DATE`
+
+// resourceList maps the rows of a query on RESOURCES.
+var resourceList = newResourceNode()
+
+// Session reads through an identity map: each row is materialised once, and
+// every read of the session returns the same instance for it. Resources shared
+// by many tasks are therefore loaded only once per session.
+type Session struct {
+	db       *sql.DB
+	identity *identitymap.Map
+}
+
+func NewSession(db *sql.DB) *Session {
+	return &Session{db: db, identity: identitymap.New()}
+}
+
+// Identity exposes the session identity map.
+func (s *Session) Identity() *identitymap.Map {
+	return s.identity
+}
+
+// ReadProject reads a project like the package-level ReadProject. Its tasks and
+// resources are the instances already loaded by the session, or are added to
+// it, so they are the ones GetResource and ListResources return.
+func (s *Session) ReadProject(projectID int, order ...orderby.OrderBy) (*entities.SessionProject, error) {
+	project, err := readProject[entities.SessionProject](context.Background(), s.db, sessionProjectTree, s.identity, projectID, order)
+	if err == nil && project == nil {
+		project = &entities.SessionProject{Project: entities.Project{ID: projectID}}
+	}
+	return project, err
+}
+
+// GetResource reads a resource by ID.
+func (s *Session) GetResource(id int) (*entities.Resource, error) {
+	if resource, exists := s.identity.Lookup("RESOURCES", id); exists {
+		return resource.(*entities.Resource), nil
+	}
+
+	rows, err := s.db.Query(`SELECT `+resourceColumns+` FROM RESOURCES WHERE ID = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := assembler.AssembleWith[entities.Resource](rows, resourceList, s.identity)
+	if err != nil {
+		return nil, err
+	}
+	if len(resources) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return resources[0], nil
+}
+
+// ListResources reads every resource, ordered by ID.
+func (s *Session) ListResources() ([]*entities.Resource, error) {
+	rows, err := s.db.Query(`SELECT ` + resourceColumns + ` FROM RESOURCES ORDER BY ID`)
+	if err != nil {
+		return nil, err
+	}
+	return assembler.AssembleWith[entities.Resource](rows, resourceList, s.identity)
+}
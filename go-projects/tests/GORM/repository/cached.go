@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"m/tests/GORM/entities"
+	"m/utils/cache"
+	"m/utils/orderby"
+
+	"gorm.io/gorm"
+)
+
+// CachedRepository is the package functions behind a read-through cache.
+type CachedRepository = cache.Repository[entities.Resource, entities.Project]
+
+func NewCachedRepository(db *gorm.DB, c *cache.Cache) *CachedRepository {
+	return cache.NewRepository(c, "GORM", cache.RepositoryFuncs[entities.Resource, entities.Project]{
+		InsertResource: func(resource entities.Resource) (int, error) { return InsertResource(db, resource) },
+		InsertProject:  func(project entities.Project) (int, error) { return InsertProject(db, project) },
+		ReadProject: func(projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+			return ReadProject(db, projectID, order...)
+		},
+		UpdateProject: func(project *entities.Project) error { return UpdateProject(db, project) },
+		DeleteProject: func(projectID int) error { return DeleteProject(db, projectID) },
+	})
+}
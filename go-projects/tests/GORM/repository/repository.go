@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
 	"m/tests/GORM/entities"
+	"m/utils/orderby"
+	"m/utils/trace"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -9,6 +12,10 @@ import (
 
 // InsertResource inserts a new resource into the RESOURCES table.
 func InsertResource(db *gorm.DB, resource entities.Resource) (int, error) {
+	ctx, span := trace.Start(context.Background(), "GORM.InsertResource", "resource.id", resource.ID)
+	defer span.End()
+	db = db.WithContext(ctx)
+
 	if err := db.Create(&resource).Error; err != nil {
 		return -1, err
 	}
@@ -17,16 +24,38 @@ func InsertResource(db *gorm.DB, resource entities.Resource) (int, error) {
 
 // InsertProject inserts a new project along with its associated tasks.
 func InsertProject(db *gorm.DB, project entities.Project) (int, error) {
+	ctx, span := trace.Start(context.Background(), "GORM.InsertProject", "project.id", project.ID)
+	defer span.End()
+	db = db.WithContext(ctx)
+
 	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&project).Error; err != nil {
 		return -1, err
 	}
 	return project.ID, nil
 }
 
-// ReadProject retrieves a project by ID, including its tasks and resources associated with each task.
-func ReadProject(db *gorm.DB, projectID int) (*entities.Project, error) {
+// ReadProject retrieves a project by ID, including its tasks and resources associated with each task,
+// sorted by key or by the given order.
+func ReadProject(db *gorm.DB, projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	ctx, span := trace.Start(context.Background(), "GORM.ReadProject", "project.id", projectID)
+	defer span.End()
+	db = db.WithContext(ctx)
+
+	sortOrder := orderby.Resolve(order)
+	taskOrder, err := sortOrder.TaskClause("")
+	if err != nil {
+		return nil, err
+	}
+	resourceOrder, err := sortOrder.ResourceClause("")
+	if err != nil {
+		return nil, err
+	}
+
 	var project entities.Project
-	err := db.Preload("Tasks.Resources").First(&project, projectID).Error
+	err = db.
+		Preload("Tasks", func(tx *gorm.DB) *gorm.DB { return tx.Order(taskOrder) }).
+		Preload("Tasks.Resources", func(tx *gorm.DB) *gorm.DB { return tx.Order(resourceOrder) }).
+		First(&project, projectID).Error
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +64,10 @@ func ReadProject(db *gorm.DB, projectID int) (*entities.Project, error) {
 
 // UpdateProject updates the details of a project by ID.
 func UpdateProject(db *gorm.DB, updatedProject *entities.Project) error {
+	ctx, span := trace.Start(context.Background(), "GORM.UpdateProject", "project.id", updatedProject.ID)
+	defer span.End()
+	db = db.WithContext(ctx)
+
 	// Start a transaction
 	err := db.Transaction(func(tx *gorm.DB) error {
 		// Update the main project fields
@@ -57,5 +90,9 @@ func UpdateProject(db *gorm.DB, updatedProject *entities.Project) error {
 
 // DeleteProject deletes a project by ID.
 func DeleteProject(db *gorm.DB, projectID int) error {
+	ctx, span := trace.Start(context.Background(), "GORM.DeleteProject", "project.id", projectID)
+	defer span.End()
+	db = db.WithContext(ctx)
+
 	return db.Delete(&entities.Project{}, projectID).Error
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"m/tests"
+	base "m/tests/Base"
+	"m/tests/PGX/entities"
+	"m/tests/PGX/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+const (
+	host     = "localhost"
+	port     = 5432
+	user     = "my_user"
+	password = "my@Pass%1234"
+	dbname   = "my_database"
+)
+
+func main() {
+	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
+
+	pool, err := pgxpool.New(context.Background(), psqlInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+
+	err = pool.Ping(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Successfully connected to the database.")
+
+	db := stdlib.OpenDB(*pool.Config().ConnConfig)
+	base.ClearAllProjectsAndResources(db)
+	db.Close()
+
+	data, err := base.OpenInputData()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resources, err := base.Cast[[]entities.Resource](data.Resources)
+	if err != nil {
+		log.Fatalf("Failed to cast resources: %v", err)
+	}
+
+	_, err = repository.InsertResources(pool, resources)
+	if err != nil {
+		log.Fatalf("Failed to insert resources: %v", err)
+	}
+
+	firstProject, err := base.Cast[entities.Project](data.Projects[0])
+
+	projectId, err := repository.InsertProject(pool, firstProject)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	project, err := repository.ReadProject(pool, projectId)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tests.SaveResult("result_main_execution.json", project)
+
+	testText := "modified for testing only"
+	firstProject.Tasks[0].Description = &testText
+	firstProject.Name = "new name test"
+
+	err = repository.UpdateProject(pool, &firstProject)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	project, err = repository.ReadProject(pool, projectId)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tests.SaveResult("result_main_execution_updated.json", project)
+}
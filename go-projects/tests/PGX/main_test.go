@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	base "m/tests/Base"
+	"m/tests/PGX/entities"
+	"m/tests/PGX/repository"
+	_ "m/tests/suite"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// startupTest stores the input data through the repository, for the
+// benchmarks of this approach to read, and returns it with a connection.
+func startupTest(tb testing.TB) (*pgxpool.Pool, []entities.Resource, []entities.Project) {
+	pool := base.SetupPgx()
+
+	base.Seed(tb, "PGX")
+
+	data := base.GetInputData(tb)
+
+	resources, err := base.Cast[[]entities.Resource](data.Resources)
+	if err != nil {
+		tb.Fatalf("Failed to cast resources: %v", err)
+	}
+
+	projects, err := base.Cast[[]entities.Project](data.Projects)
+	if err != nil {
+		tb.Fatalf("Failed to cast projects: %v", err)
+	}
+
+	return pool, resources, projects
+}
+
+// Benchmark for inserting the resources with a single COPY. Compare it with
+// BenchmarkInsertResources/PGX of tests/suite, which inserts them one by one.
+func BenchmarkInsertResourcesCopy(b *testing.B) {
+	pool, resources, _ := startupTest(b)
+	defer pool.Close()
+
+	queries := base.CountQueries(b)
+	b.ResetTimer() // Start benchmark timer here to exclude setup time.
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		queries.Stop()
+		if _, err := pool.Exec(context.Background(), "TRUNCATE TASK_RESOURCE, RESOURCES;"); err != nil {
+			b.Fatalf("Error cleaning resources: %s", err)
+		}
+		queries.Start()
+		b.StartTimer()
+
+		copied, err := repository.InsertResources(pool, resources)
+		if err != nil {
+			b.Fatalf("Failed to copy resources: %v", err)
+		}
+		if copied != int64(len(resources)) {
+			b.Fatalf("Copied %d resources, expected %d", copied, len(resources))
+		}
+	}
+	queries.Report()
+}
@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"m/tests/PGX/entities"
+	"m/utils/orderby"
+	"m/utils/trace"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// InsertResource inserts a single resource into the RESOURCES table.
+func InsertResource(pool *pgxpool.Pool, resource entities.Resource) (int, error) {
+	ctx, span := trace.Start(context.Background(), "PGX.InsertResource", "resource.id", resource.ID)
+	defer span.End()
+
+	query := `
+		INSERT INTO RESOURCES (ID, TYPE, NAME, DAILY_COST, STATUS, SUPPLIER, QUANTITY, ACQUISITION_DATE)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ID
+	`
+	var resourceID int
+	err := pool.QueryRow(ctx, query, resource.ID, resource.Type, resource.Name, resource.DailyCost, resource.Status, resource.Supplier, resource.Quantity, resource.AcquisitionDate).Scan(&resourceID)
+	if err != nil {
+		return 0, err
+	}
+
+	return resourceID, nil
+}
+
+// resourceColumns are the columns that InsertResources copies, in order.
+var resourceColumns = []string{"id", "type", "name", "daily_cost", "status", "supplier", "quantity", "acquisition_date"}
+
+// InsertResources inserts resources with a single COPY and returns the number
+// of rows copied.
+func InsertResources(pool *pgxpool.Pool, resources []entities.Resource) (int64, error) {
+	ctx, span := trace.Start(context.Background(), "PGX.InsertResources", "resources", len(resources))
+	defer span.End()
+
+	return pool.CopyFrom(ctx, pgx.Identifier{"resources"}, resourceColumns,
+		pgx.CopyFromSlice(len(resources), func(i int) ([]any, error) {
+			r := resources[i]
+			return []any{r.ID, r.Type, r.Name, r.DailyCost, r.Status, r.Supplier, r.Quantity, r.AcquisitionDate}, nil
+		}))
+}
+
+// InsertProject inserts a project along with its tasks and linked resources.
+// The statements are queued in a batch and sent in one round trip, which
+// PostgreSQL runs in an implicit transaction.
+func InsertProject(pool *pgxpool.Pool, project entities.Project) (int, error) {
+	ctx, span := trace.Start(context.Background(), "PGX.InsertProject", "project.id", project.ID)
+	defer span.End()
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		INSERT INTO PROJECTS (ID, NAME, MANAGER, START_DATE, END_DATE, BUDGET, DESCRIPTION)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, project.ID, project.Name, project.Manager, project.StartDate, project.EndDate, project.Budget, project.Description)
+
+	for _, task := range project.Tasks {
+		batch.Queue(`
+			INSERT INTO TASKS (ID, NAME, RESPONSIBLE, DEADLINE, STATUS, PRIORITY, ESTIMATED_TIME, PROJECT_ID, DESCRIPTION)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, task.ID, task.Name, task.Responsible, task.Deadline, task.Status, task.Priority, task.EstimatedTime, project.ID, task.Description)
+
+		for _, resource := range task.Resources {
+			batch.Queue(`
+				INSERT INTO TASK_RESOURCE (TASK_ID, RESOURCE_ID, QUANTITY_USED)
+				VALUES ($1, $2, $3)
+			`, task.ID, resource.ID, resource.Quantity)
+		}
+	}
+
+	if err := pool.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, err
+	}
+	return project.ID, nil
+}
+
+// projectRow is a row of the ReadProject join. Tasks and resources come from
+// LEFT JOINs, so their columns may all be NULL.
+type projectRow struct {
+	ID                  int
+	Name                string
+	Manager             string
+	StartDate           time.Time
+	EndDate             *time.Time
+	Budget              *float64
+	Description         *string
+	TaskID              *int
+	TaskName            *string
+	TaskResponsible     *string
+	TaskDeadline        *time.Time
+	TaskStatus          *string
+	TaskPriority        *string
+	TaskEstimatedTime   *string
+	TaskDescription     *string
+	ResourceID          *int
+	ResourceType        *string
+	ResourceName        *string
+	ResourceDailyCost   *float64
+	ResourceStatus      *string
+	ResourceSupplier    *string
+	ResourceQuantity    *int
+	ResourceAcquisition *time.Time
+}
+
+// Reads a project by ID, including its tasks and resources sorted by key or by the given order.
+func ReadProject(pool *pgxpool.Pool, projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	ctx, span := trace.Start(context.Background(), "PGX.ReadProject", "project.id", projectID)
+	defer span.End()
+
+	sortOrder, err := orderby.Resolve(order).JoinClause("t", "r")
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT 
+		p.ID, 
+		p.NAME, 
+		p.MANAGER, 
+		p.START_DATE, 
+		p.END_DATE, 
+		p.BUDGET, 
+		p.DESCRIPTION, 
+		t.ID, 
+		t.NAME, 
+		t.RESPONSIBLE, 
+		t.DEADLINE, 
+		t.STATUS, 
+		t.PRIORITY, 
+		t.ESTIMATED_TIME::TEXT, 
+		t.DESCRIPTION,
+		r.ID, 
+		r.TYPE, 
+		r.NAME, 
+		r.DAILY_COST, 
+		r.STATUS, 
+		r.SUPPLIER, 
+		r.QUANTITY, 
+		r.ACQUISITION_DATE
+	FROM PROJECTS p
+		LEFT JOIN TASKS t ON p.ID = t.PROJECT_ID
+		LEFT JOIN TASK_RESOURCE tr ON t.ID = tr.TASK_ID
+		LEFT JOIN RESOURCES r ON r.ID = tr.RESOURCE_ID
+	WHERE p.ID = $1
+	ORDER BY ` + sortOrder
+
+	rows, err := pool.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	projectRows, err := pgx.CollectRows(rows, pgx.RowToStructByPos[projectRow])
+	if err != nil {
+		return nil, err
+	}
+	if len(projectRows) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return assemble(projectRows), nil
+}
+
+// assemble builds the project from its join rows, which are sorted by task
+// and list each task once per resource.
+func assemble(rows []projectRow) *entities.Project {
+	first := rows[0]
+	project := &entities.Project{
+		ID:          first.ID,
+		Name:        first.Name,
+		Manager:     first.Manager,
+		StartDate:   first.StartDate,
+		EndDate:     first.EndDate,
+		Budget:      first.Budget,
+		Description: first.Description,
+	}
+
+	var task *entities.Task
+	for _, row := range rows {
+		if row.TaskID == nil {
+			continue
+		}
+		if task == nil || task.ID != *row.TaskID {
+			project.Tasks = append(project.Tasks, entities.Task{
+				ID:            *row.TaskID,
+				Name:          *row.TaskName,
+				Responsible:   row.TaskResponsible,
+				Deadline:      *row.TaskDeadline,
+				Status:        *row.TaskStatus,
+				Priority:      row.TaskPriority,
+				EstimatedTime: row.TaskEstimatedTime,
+				Description:   row.TaskDescription,
+			})
+			task = &project.Tasks[len(project.Tasks)-1]
+		}
+		if row.ResourceID == nil {
+			continue
+		}
+		task.Resources = append(task.Resources, entities.Resource{
+			ID:              *row.ResourceID,
+			Type:            *row.ResourceType,
+			Name:            *row.ResourceName,
+			DailyCost:       row.ResourceDailyCost,
+			Status:          *row.ResourceStatus,
+			Supplier:        row.ResourceSupplier,
+			Quantity:        row.ResourceQuantity,
+			AcquisitionDate: row.ResourceAcquisition,
+		})
+	}
+	return project
+}
+
+// UpdateProject updates a project and its associated tasks in one batch.
+func UpdateProject(pool *pgxpool.Pool, project *entities.Project) error {
+	ctx, span := trace.Start(context.Background(), "PGX.UpdateProject", "project.id", project.ID)
+	defer span.End()
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		UPDATE PROJECTS
+		SET NAME = $1, MANAGER = $2, START_DATE = $3, END_DATE = $4, BUDGET = $5, DESCRIPTION = $6
+		WHERE ID = $7
+	`, project.Name, project.Manager, project.StartDate, project.EndDate, project.Budget, project.Description, project.ID)
+
+	for _, task := range project.Tasks {
+		batch.Queue(`
+			UPDATE TASKS
+				SET NAME = $1, 
+				RESPONSIBLE = $2, 
+				DEADLINE = $3, 
+				STATUS = $4, 
+				PRIORITY = $5, 
+				ESTIMATED_TIME = $6,
+				DESCRIPTION = $7
+			WHERE ID = $8 AND PROJECT_ID = $9
+		`, task.Name, task.Responsible, task.Deadline, task.Status, task.Priority, task.EstimatedTime, task.Description, task.ID, project.ID)
+	}
+
+	return pool.SendBatch(ctx, batch).Close()
+}
+
+// Deletes a project by ID.
+func DeleteProject(pool *pgxpool.Pool, projectID int) error {
+	ctx, span := trace.Start(context.Background(), "PGX.DeleteProject", "project.id", projectID)
+	defer span.End()
+
+	_, err := pool.Exec(ctx, `
+		DELETE FROM PROJECTS
+		WHERE ID = $1
+	`, projectID)
+	return err
+}
@@ -0,0 +1,5 @@
+// Package db holds the queries of the SQLGen approach, generated by
+// cmd/sqlgen from queries.sql and database/schema.sql.
+package db
+
+//go:generate go run m/cmd/sqlgen -schema ../../../../database/schema.sql -queries queries.sql -o queries.go
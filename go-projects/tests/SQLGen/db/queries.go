@@ -0,0 +1,240 @@
+// Code generated by sqlgen. DO NOT EDIT.
+// source: queries.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DBTX is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// New returns the queries running on db.
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+// Queries runs the generated queries.
+type Queries struct {
+	db DBTX
+}
+
+// WithTx returns the queries running in tx.
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return &Queries{db: tx}
+}
+
+const insertResource = `-- name: InsertResource :one
+INSERT INTO RESOURCES (ID, TYPE, NAME, DAILY_COST, STATUS, SUPPLIER, QUANTITY, ACQUISITION_DATE)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING ID
+`
+
+type InsertResourceParams struct {
+	ID              int32
+	Type            string
+	Name            string
+	DailyCost       sql.NullFloat64
+	Status          string
+	Supplier        sql.NullString
+	Quantity        sql.NullInt32
+	AcquisitionDate sql.NullTime
+}
+
+func (q *Queries) InsertResource(ctx context.Context, arg InsertResourceParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, insertResource, arg.ID, arg.Type, arg.Name, arg.DailyCost, arg.Status, arg.Supplier, arg.Quantity, arg.AcquisitionDate)
+	var i int32
+	err := row.Scan(&i)
+	return i, err
+}
+
+const insertProject = `-- name: InsertProject :one
+INSERT INTO PROJECTS (ID, NAME, MANAGER, START_DATE, END_DATE, BUDGET, DESCRIPTION)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING ID
+`
+
+type InsertProjectParams struct {
+	ID          int32
+	Name        string
+	Manager     string
+	StartDate   time.Time
+	EndDate     sql.NullTime
+	Budget      sql.NullFloat64
+	Description sql.NullString
+}
+
+func (q *Queries) InsertProject(ctx context.Context, arg InsertProjectParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, insertProject, arg.ID, arg.Name, arg.Manager, arg.StartDate, arg.EndDate, arg.Budget, arg.Description)
+	var i int32
+	err := row.Scan(&i)
+	return i, err
+}
+
+const insertTask = `-- name: InsertTask :one
+INSERT INTO TASKS (ID, NAME, RESPONSIBLE, DEADLINE, STATUS, PRIORITY, ESTIMATED_TIME, PROJECT_ID, DESCRIPTION)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING ID
+`
+
+type InsertTaskParams struct {
+	ID            int32
+	Name          string
+	Responsible   sql.NullString
+	Deadline      time.Time
+	Status        string
+	Priority      sql.NullString
+	EstimatedTime sql.NullString
+	ProjectID     sql.NullInt32
+	Description   sql.NullString
+}
+
+func (q *Queries) InsertTask(ctx context.Context, arg InsertTaskParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, insertTask, arg.ID, arg.Name, arg.Responsible, arg.Deadline, arg.Status, arg.Priority, arg.EstimatedTime, arg.ProjectID, arg.Description)
+	var i int32
+	err := row.Scan(&i)
+	return i, err
+}
+
+const linkTaskResource = `-- name: LinkTaskResource :exec
+INSERT INTO TASK_RESOURCE (TASK_ID, RESOURCE_ID, QUANTITY_USED)
+VALUES ($1, $2, $3)
+`
+
+type LinkTaskResourceParams struct {
+	TaskID       int32
+	ResourceID   int32
+	QuantityUsed sql.NullInt32
+}
+
+func (q *Queries) LinkTaskResource(ctx context.Context, arg LinkTaskResourceParams) error {
+	_, err := q.db.ExecContext(ctx, linkTaskResource, arg.TaskID, arg.ResourceID, arg.QuantityUsed)
+	return err
+}
+
+const readProject = `-- name: ReadProject :many
+SELECT
+    p.ID, p.NAME, p.MANAGER, p.START_DATE, p.END_DATE, p.BUDGET, p.DESCRIPTION,
+    t.ID AS TASK_ID, t.NAME AS TASK_NAME, t.RESPONSIBLE, t.DEADLINE, t.STATUS AS TASK_STATUS,
+    t.PRIORITY, t.ESTIMATED_TIME, t.DESCRIPTION AS TASK_DESCRIPTION,
+    r.ID AS RESOURCE_ID, r.TYPE, r.NAME AS RESOURCE_NAME, r.DAILY_COST, r.STATUS AS RESOURCE_STATUS,
+    r.SUPPLIER, r.QUANTITY, r.ACQUISITION_DATE
+FROM PROJECTS p
+    LEFT JOIN TASKS t ON p.ID = t.PROJECT_ID
+    LEFT JOIN TASK_RESOURCE tr ON t.ID = tr.TASK_ID
+    LEFT JOIN RESOURCES r ON r.ID = tr.RESOURCE_ID
+WHERE p.ID = $1
+ORDER BY t.ID, r.ID
+`
+
+type ReadProjectRow struct {
+	ID              int32
+	Name            string
+	Manager         string
+	StartDate       time.Time
+	EndDate         sql.NullTime
+	Budget          sql.NullFloat64
+	Description     sql.NullString
+	TaskID          sql.NullInt32
+	TaskName        sql.NullString
+	Responsible     sql.NullString
+	Deadline        sql.NullTime
+	TaskStatus      sql.NullString
+	Priority        sql.NullString
+	EstimatedTime   sql.NullString
+	TaskDescription sql.NullString
+	ResourceID      sql.NullInt32
+	Type            sql.NullString
+	ResourceName    sql.NullString
+	DailyCost       sql.NullFloat64
+	ResourceStatus  sql.NullString
+	Supplier        sql.NullString
+	Quantity        sql.NullInt32
+	AcquisitionDate sql.NullTime
+}
+
+// ReadProject returns one row per task and resource of the project, sorted
+// by key; a project without tasks has a single row.
+func (q *Queries) ReadProject(ctx context.Context, id int32) ([]ReadProjectRow, error) {
+	rows, err := q.db.QueryContext(ctx, readProject, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadProjectRow
+	for rows.Next() {
+		var i ReadProjectRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Manager, &i.StartDate, &i.EndDate, &i.Budget, &i.Description, &i.TaskID, &i.TaskName, &i.Responsible, &i.Deadline, &i.TaskStatus, &i.Priority, &i.EstimatedTime, &i.TaskDescription, &i.ResourceID, &i.Type, &i.ResourceName, &i.DailyCost, &i.ResourceStatus, &i.Supplier, &i.Quantity, &i.AcquisitionDate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateProject = `-- name: UpdateProject :exec
+UPDATE PROJECTS
+SET NAME = $1, MANAGER = $2, START_DATE = $3, END_DATE = $4, BUDGET = $5, DESCRIPTION = $6
+WHERE ID = $7
+`
+
+type UpdateProjectParams struct {
+	Name        string
+	Manager     string
+	StartDate   time.Time
+	EndDate     sql.NullTime
+	Budget      sql.NullFloat64
+	Description sql.NullString
+	ID          int32
+}
+
+func (q *Queries) UpdateProject(ctx context.Context, arg UpdateProjectParams) error {
+	_, err := q.db.ExecContext(ctx, updateProject, arg.Name, arg.Manager, arg.StartDate, arg.EndDate, arg.Budget, arg.Description, arg.ID)
+	return err
+}
+
+const updateTask = `-- name: UpdateTask :exec
+UPDATE TASKS
+SET NAME = $1, RESPONSIBLE = $2, DEADLINE = $3, STATUS = $4, PRIORITY = $5, ESTIMATED_TIME = $6, DESCRIPTION = $7
+WHERE ID = $8 AND PROJECT_ID = $9
+`
+
+type UpdateTaskParams struct {
+	Name          string
+	Responsible   sql.NullString
+	Deadline      time.Time
+	Status        string
+	Priority      sql.NullString
+	EstimatedTime sql.NullString
+	Description   sql.NullString
+	ID            int32
+	ProjectID     sql.NullInt32
+}
+
+func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) error {
+	_, err := q.db.ExecContext(ctx, updateTask, arg.Name, arg.Responsible, arg.Deadline, arg.Status, arg.Priority, arg.EstimatedTime, arg.Description, arg.ID, arg.ProjectID)
+	return err
+}
+
+const deleteProject = `-- name: DeleteProject :exec
+DELETE FROM PROJECTS
+WHERE ID = $1
+`
+
+func (q *Queries) DeleteProject(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteProject, id)
+	return err
+}
@@ -0,0 +1,51 @@
+-- Queries of the SQLGen approach, taken from the DirectStruct repository.
+-- Run go generate in this directory after changing them.
+
+-- name: InsertResource :one
+INSERT INTO RESOURCES (ID, TYPE, NAME, DAILY_COST, STATUS, SUPPLIER, QUANTITY, ACQUISITION_DATE)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING ID;
+
+-- name: InsertProject :one
+INSERT INTO PROJECTS (ID, NAME, MANAGER, START_DATE, END_DATE, BUDGET, DESCRIPTION)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING ID;
+
+-- name: InsertTask :one
+INSERT INTO TASKS (ID, NAME, RESPONSIBLE, DEADLINE, STATUS, PRIORITY, ESTIMATED_TIME, PROJECT_ID, DESCRIPTION)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING ID;
+
+-- name: LinkTaskResource :exec
+INSERT INTO TASK_RESOURCE (TASK_ID, RESOURCE_ID, QUANTITY_USED)
+VALUES ($1, $2, $3);
+
+-- name: ReadProject :many
+-- ReadProject returns one row per task and resource of the project, sorted
+-- by key; a project without tasks has a single row.
+SELECT
+    p.ID, p.NAME, p.MANAGER, p.START_DATE, p.END_DATE, p.BUDGET, p.DESCRIPTION,
+    t.ID AS TASK_ID, t.NAME AS TASK_NAME, t.RESPONSIBLE, t.DEADLINE, t.STATUS AS TASK_STATUS,
+    t.PRIORITY, t.ESTIMATED_TIME, t.DESCRIPTION AS TASK_DESCRIPTION,
+    r.ID AS RESOURCE_ID, r.TYPE, r.NAME AS RESOURCE_NAME, r.DAILY_COST, r.STATUS AS RESOURCE_STATUS,
+    r.SUPPLIER, r.QUANTITY, r.ACQUISITION_DATE
+FROM PROJECTS p
+    LEFT JOIN TASKS t ON p.ID = t.PROJECT_ID
+    LEFT JOIN TASK_RESOURCE tr ON t.ID = tr.TASK_ID
+    LEFT JOIN RESOURCES r ON r.ID = tr.RESOURCE_ID
+WHERE p.ID = $1
+ORDER BY t.ID, r.ID;
+
+-- name: UpdateProject :exec
+UPDATE PROJECTS
+SET NAME = $1, MANAGER = $2, START_DATE = $3, END_DATE = $4, BUDGET = $5, DESCRIPTION = $6
+WHERE ID = $7;
+
+-- name: UpdateTask :exec
+UPDATE TASKS
+SET NAME = $1, RESPONSIBLE = $2, DEADLINE = $3, STATUS = $4, PRIORITY = $5, ESTIMATED_TIME = $6, DESCRIPTION = $7
+WHERE ID = $8 AND PROJECT_ID = $9;
+
+-- name: DeleteProject :exec
+DELETE FROM PROJECTS
+WHERE ID = $1;
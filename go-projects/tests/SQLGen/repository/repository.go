@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"m/tests/SQLGen/db"
+	"m/tests/SQLGen/entities"
+	"m/utils/orderby"
+	"m/utils/trace"
+)
+
+// InsertResource inserts a single resource into the RESOURCES table.
+func InsertResource(conn *sql.DB, resource entities.Resource) (int, error) {
+	ctx, span := trace.Start(context.Background(), "SQLGen.InsertResource", "resource.id", resource.ID)
+	defer span.End()
+
+	resourceID, err := db.New(conn).InsertResource(ctx, db.InsertResourceParams{
+		ID:              int32(resource.ID),
+		Type:            resource.Type,
+		Name:            resource.Name,
+		DailyCost:       nullFloat(resource.DailyCost),
+		Status:          resource.Status,
+		Supplier:        nullString(resource.Supplier),
+		Quantity:        nullInt(resource.Quantity),
+		AcquisitionDate: nullTime(resource.AcquisitionDate),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(resourceID), nil
+}
+
+// InsertProject inserts a project along with its tasks and linked resources.
+func InsertProject(conn *sql.DB, project entities.Project) (int, error) {
+	ctx, span := trace.Start(context.Background(), "SQLGen.InsertProject", "project.id", project.ID)
+	defer span.End()
+
+	queries := db.New(conn)
+
+	projectID, err := queries.InsertProject(ctx, db.InsertProjectParams{
+		ID:          int32(project.ID),
+		Name:        project.Name,
+		Manager:     project.Manager,
+		StartDate:   project.StartDate,
+		EndDate:     nullTime(project.EndDate),
+		Budget:      nullFloat(project.Budget),
+		Description: nullString(project.Description),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, task := range project.Tasks {
+		taskID, err := queries.InsertTask(ctx, db.InsertTaskParams{
+			ID:            int32(task.ID),
+			Name:          task.Name,
+			Responsible:   nullString(task.Responsible),
+			Deadline:      task.Deadline,
+			Status:        task.Status,
+			Priority:      nullString(task.Priority),
+			EstimatedTime: nullString(task.EstimatedTime),
+			ProjectID:     sql.NullInt32{Int32: projectID, Valid: true},
+			Description:   nullString(task.Description),
+		})
+		if err != nil {
+			return int(projectID), err
+		}
+
+		for _, resource := range task.Resources {
+			err := queries.LinkTaskResource(ctx, db.LinkTaskResourceParams{
+				TaskID:       taskID,
+				ResourceID:   int32(resource.ID),
+				QuantityUsed: nullInt(resource.Quantity),
+			})
+			if err != nil {
+				return int(projectID), err
+			}
+		}
+	}
+
+	return int(projectID), nil
+}
+
+// Reads a project by ID, including its tasks and resources sorted by key. The
+// generated query is static, so no other order can be requested.
+func ReadProject(conn *sql.DB, projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	ctx, span := trace.Start(context.Background(), "SQLGen.ReadProject", "project.id", projectID)
+	defer span.End()
+
+	if resolved := orderby.Resolve(order); resolved != orderby.ByKey {
+		return nil, fmt.Errorf("the generated ReadProject only sorts by key, got %+v", resolved)
+	}
+
+	rows, err := db.New(conn).ReadProject(ctx, int32(projectID))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return assemble(rows), nil
+}
+
+// assemble builds the project from the rows of ReadProject, which come sorted
+// by task and resource.
+func assemble(rows []db.ReadProjectRow) *entities.Project {
+	first := rows[0]
+	project := &entities.Project{
+		ID:          int(first.ID),
+		Name:        first.Name,
+		Manager:     first.Manager,
+		StartDate:   first.StartDate,
+		EndDate:     timePtr(first.EndDate),
+		Budget:      floatPtr(first.Budget),
+		Description: stringPtr(first.Description),
+	}
+
+	for _, row := range rows {
+		if !row.TaskID.Valid {
+			continue
+		}
+		last := len(project.Tasks) - 1
+		if last < 0 || project.Tasks[last].ID != int(row.TaskID.Int32) {
+			project.Tasks = append(project.Tasks, entities.Task{
+				ID:            int(row.TaskID.Int32),
+				Name:          row.TaskName.String,
+				Responsible:   stringPtr(row.Responsible),
+				Deadline:      row.Deadline.Time,
+				Status:        row.TaskStatus.String,
+				Priority:      stringPtr(row.Priority),
+				EstimatedTime: stringPtr(row.EstimatedTime),
+				Description:   stringPtr(row.TaskDescription),
+			})
+			last++
+		}
+		if !row.ResourceID.Valid {
+			continue
+		}
+		task := &project.Tasks[last]
+		task.Resources = append(task.Resources, entities.Resource{
+			ID:              int(row.ResourceID.Int32),
+			Type:            row.Type.String,
+			Name:            row.ResourceName.String,
+			DailyCost:       floatPtr(row.DailyCost),
+			Status:          row.ResourceStatus.String,
+			Supplier:        stringPtr(row.Supplier),
+			Quantity:        intPtr(row.Quantity),
+			AcquisitionDate: timePtr(row.AcquisitionDate),
+		})
+	}
+	return project
+}
+
+// UpdateProject updates a project and its associated tasks.
+func UpdateProject(conn *sql.DB, project *entities.Project) error {
+	ctx, span := trace.Start(context.Background(), "SQLGen.UpdateProject", "project.id", project.ID)
+	defer span.End()
+
+	queries := db.New(conn)
+
+	err := queries.UpdateProject(ctx, db.UpdateProjectParams{
+		Name:        project.Name,
+		Manager:     project.Manager,
+		StartDate:   project.StartDate,
+		EndDate:     nullTime(project.EndDate),
+		Budget:      nullFloat(project.Budget),
+		Description: nullString(project.Description),
+		ID:          int32(project.ID),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, task := range project.Tasks {
+		err := queries.UpdateTask(ctx, db.UpdateTaskParams{
+			Name:          task.Name,
+			Responsible:   nullString(task.Responsible),
+			Deadline:      task.Deadline,
+			Status:        task.Status,
+			Priority:      nullString(task.Priority),
+			EstimatedTime: nullString(task.EstimatedTime),
+			Description:   nullString(task.Description),
+			ID:            int32(task.ID),
+			ProjectID:     sql.NullInt32{Int32: int32(project.ID), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deletes a project by ID.
+func DeleteProject(conn *sql.DB, projectID int) error {
+	ctx, span := trace.Start(context.Background(), "SQLGen.DeleteProject", "project.id", projectID)
+	defer span.End()
+
+	return db.New(conn).DeleteProject(ctx, int32(projectID))
+}
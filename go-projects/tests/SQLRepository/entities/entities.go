@@ -139,3 +139,17 @@ func (r *Resource) PKColNames() []string {
 func (r *Resource) PKFields() []interface{} {
 	return []interface{}{&r.ID}
 }
+
+// SessionProject is a project read through a repository session. Its tasks,
+// and their resources, are the instances held by the session, so a resource
+// used by many tasks is a single value shared with every other read.
+type SessionProject struct {
+	Project
+	Tasks []*SessionTask `json:"tasks"`
+}
+
+// SessionTask is a task of a SessionProject.
+type SessionTask struct {
+	Task
+	Resources []*Resource `json:"resources"`
+}
@@ -5,128 +5,150 @@ import (
 	base "m/tests/Base"
 	"m/tests/SQLRepository/entities"
 	"m/tests/SQLRepository/repository"
+	_ "m/tests/suite"
+	"m/utils/cache"
 	"testing"
-	"time"
 
 	_ "github.com/lib/pq"
 )
 
-func startupTest(b *testing.B) (*sql.DB, []entities.Resource, []entities.Project) {
+// startupTest stores the input data through the repository, for the
+// benchmarks of this approach to read, and returns it with a connection.
+func startupTest(tb testing.TB) (*sql.DB, []entities.Resource, []entities.Project) {
 	db := base.SetupDB()
 
-	data := base.GetInputData(b)
+	base.Seed(tb, "SQLRepository")
+
+	data := base.GetInputData(tb)
 
 	resources, err := base.Cast[[]entities.Resource](data.Resources)
 	if err != nil {
-		b.Fatalf("Failed to cast resources: %v", err)
+		tb.Fatalf("Failed to cast resources: %v", err)
 	}
 
 	projects, err := base.Cast[[]entities.Project](data.Projects)
 	if err != nil {
-		b.Fatalf("Failed to cast projects: %v", err)
+		tb.Fatalf("Failed to cast projects: %v", err)
 	}
 
 	return db, resources, projects
 }
 
-// Benchmark for inserting a resources.
-func BenchmarkInsertResources(b *testing.B) {
-	db, resources, _ := startupTest(b)
+// BenchmarkReadProjectSession measures the memory a session identity map saves.
+// The Plain run reads every project with ReadProject, the Session run reads
+// them through one session per iteration, which materialises each resource
+// once however many tasks use it: compare their B/op and allocs/op.
+func BenchmarkReadProjectSession(b *testing.B) {
+	db, _, projects := startupTest(b)
 	defer db.Close()
 
-	err := base.ClearAllProjectsAndResources(db)
-	if err != nil {
-		b.Fatalf("Error cleaning database: %s", err)
+	session := repository.NewSession(db)
+	for _, project := range projects {
+		readProject, err := session.ReadProject(project.ID)
+		if err != nil {
+			b.Fatalf("Failed to read project: %v", err)
+		}
+		if err := base.CompareObjects(project, *readProject); err != nil {
+			b.Errorf("Objects do not match:\n%v", err)
+		}
 	}
 
-	b.ResetTimer() // Start benchmark timer here to exclude setup time.
+	b.Run("Plain", func(b *testing.B) {
+		b.ReportAllocs()
+		queries := base.CountQueries(b)
+		b.ResetTimer()
 
-	for i := 0; i < b.N; i++ {
-		for _, resource := range resources {
-			_, err := repository.InsertResource(db, resource)
-			if err != nil {
-				b.Fatalf("Failed to insert resource: %v", err)
+		for i := 0; i < b.N; i++ {
+			for _, project := range projects {
+				if _, err := repository.ReadProject(db, project.ID); err != nil {
+					b.Fatalf("Failed to read project: %v", err)
+				}
 			}
 		}
-	}
+		queries.Report()
+	})
+
+	b.Run("Session", func(b *testing.B) {
+		b.ReportAllocs()
+		queries := base.CountQueries(b)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			session := repository.NewSession(db)
+			for _, project := range projects {
+				if _, err := session.ReadProject(project.ID); err != nil {
+					b.Fatalf("Failed to read project: %v", err)
+				}
+			}
+		}
+		queries.Report()
+	})
 }
 
-// Benchmark for inserting a project.
-func BenchmarkInsertProject(b *testing.B) {
+// BenchmarkReadProjectCached measures ReadProject through the read-through cache.
+// Only the first iteration reaches the database, so compare it with
+// BenchmarkReadProject/SQLRepository of tests/suite to see the cost of a cache hit.
+func BenchmarkReadProjectCached(b *testing.B) {
 	db, _, projects := startupTest(b)
 	defer db.Close()
+	cached, err := repository.NewCachedSQLRepository(db, cache.New(len(projects), 0), repository.ProjectSchema)
+	if err != nil {
+		b.Fatalf("Failed to create repository: %v", err)
+	}
 
-	b.ResetTimer() // Start benchmark timer here to exclude setup time.
-
-	for i := 0; i < b.N; i++ {
-		for _, project := range projects {
-			_, err := repository.InsertProject(db, project)
-			if err != nil {
-				b.Fatalf("Failed to insert project: %v", err)
-			}
+	// Check the reads through a cache of their own, so that the timed cache
+	// starts empty.
+	checked, err := repository.NewCachedSQLRepository(db, cache.New(len(projects), 0), repository.ProjectSchema)
+	if err != nil {
+		b.Fatalf("Failed to create repository: %v", err)
+	}
+	for _, project := range projects {
+		readProject, err := checked.ReadProject(project.ID)
+		if err != nil {
+			b.Fatalf("Failed to read project: %v", err)
+		}
+		if err := base.CompareObjects(project, *readProject); err != nil {
+			b.Errorf("Objects do not match:\n%v", err)
 		}
 	}
-}
-
-// BenchmarkReadProject measures the performance of the ReadProject method.
-func BenchmarkReadProject(b *testing.B) {
-	db, _, projects := startupTest(b)
-	defer db.Close()
 
+	queries := base.CountQueries(b)
 	b.ResetTimer() // Start benchmark timer here to exclude setup time.
 
 	for i := 0; i < b.N; i++ {
 		for _, project := range projects {
-			readProject, err := repository.ReadProject(db, project.ID)
-			if err != nil {
+			if _, err := cached.ReadProject(project.ID); err != nil {
 				b.Fatalf("Failed to read project: %v", err)
 			}
-
-			if base.CompareObjectsAsJSON(project, *readProject) != nil {
-				b.Errorf("Objects do not match.")
-			}
 		}
 	}
+	b.ReportMetric(cached.Cache().Stats().HitRatio(), "hit-ratio")
+	queries.Report()
 }
 
-// Benchmark for updating a project.
-func BenchmarkUpdateProject(b *testing.B) {
+// Benchmark for inserting the projects through a unit of work.
+func BenchmarkInsertProjectBatched(b *testing.B) {
 	db, _, projects := startupTest(b)
 	defer db.Close()
 
+	queries := base.CountQueries(b)
 	b.ResetTimer() // Start benchmark timer here to exclude setup time.
 
 	for i := 0; i < b.N; i++ {
-		for _, project := range projects {
-			updatedProject := project
-			updatedProject.Name = "new name"
-			if len(updatedProject.Tasks) > 0 {
-				updatedProject.Tasks[0].Deadline = time.Now()
-				if len(updatedProject.Tasks[0].Resources) > 0 {
-					updatedProject.Tasks[0].Resources[0].DailyCost = new(float64)
-					*updatedProject.Tasks[0].Resources[0].DailyCost = 3.14
-				}
-			}
-			err := repository.UpdateProject(db, &updatedProject)
-			if err != nil {
-				b.Fatalf("Failed to update project: %v", err)
-			}
+		b.StopTimer()
+		queries.Stop()
+		if err := base.TruncateProjects(db); err != nil {
+			b.Fatalf("Error cleaning projects: %s", err)
 		}
-	}
-}
+		queries.Start()
+		b.StartTimer()
 
-// Benchmark for deleting a project.
-func BenchmarkDeleteProject(b *testing.B) {
-	db, _, projects := startupTest(b)
-	defer db.Close()
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
 		for _, project := range projects {
-			err := repository.DeleteProject(db, project.ID)
+			_, err := repository.InsertProjectBatched(db, project)
 			if err != nil {
-				b.Fatalf("Failed to delete project: %v", err)
+				b.Fatalf("Failed to insert project: %v", err)
 			}
 		}
 	}
+	queries.Report()
 }
@@ -1,28 +1,40 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	columnfieldmap "m/tests/SQLRepository/columnFieldMap"
 	"m/tests/SQLRepository/entities"
-	"time"
+	"m/utils/assembler"
+	"m/utils/orderby"
+	"m/utils/trace"
+	"strings"
 )
 
 // InsertResource inserts a new resource into the RESOURCES table.
 func InsertResource(db *sql.DB, resource entities.Resource) (int, error) {
+	ctx, span := trace.Start(context.Background(), "SQLRepository.InsertResource", "resource.id", resource.ID)
+	defer span.End()
+
 	repo, err := NewSQLRepository(db)
 	if err != nil {
 		panic(err)
 	}
+	repo = repo.WithContext(ctx)
 	err = repo.Insert(&resource)
 	return resource.ID, err
 }
 
 // InsertProject inserts a new project along with its associated tasks.
 func InsertProject(db *sql.DB, project entities.Project) (int, error) {
+	ctx, span := trace.Start(context.Background(), "SQLRepository.InsertProject", "project.id", project.ID)
+	defer span.End()
+
 	repo, err := NewSQLRepository(db)
 	if err != nil {
 		panic(err)
 	}
+	repo = repo.WithContext(ctx)
 	err = repo.Insert(&project)
 	if err != nil {
 		return -1, err
@@ -46,123 +58,143 @@ func InsertProject(db *sql.DB, project entities.Project) (int, error) {
 	return project.ID, err
 }
 
-// ReadProject retrieves a project by ID, including its tasks and resources associated with each task.
-func ReadProject(db *sql.DB, projectID int) (*entities.Project, error) {
-	query := `
-	SELECT 
-		p.NAME, 
-		p.MANAGER, 
-		p.START_DATE, 
-		p.END_DATE, 
-		p.BUDGET, 
-		p.DESCRIPTION, 
-		t.ID, 
-		t.NAME, 
-		t.RESPONSIBLE, 
-		t.DEADLINE, 
-		t.STATUS, 
-		t.PRIORITY, 
-		t.ESTIMATED_TIME, 
-		t.DESCRIPTION,
-		r.ID, 
-		r.TYPE, 
-		r.NAME, 
-		r.DAILY_COST, 
-		r.STATUS, 
-		r.SUPPLIER, 
-		r.QUANTITY, 
-		r.ACQUISITION_DATE
+// InsertProjectBatched inserts a project, its tasks and their resource links
+// through a UnitOfWork, in one transaction with multi-row statements.
+func InsertProjectBatched(db *sql.DB, project entities.Project) (int, error) {
+	ctx, span := trace.Start(context.Background(), "SQLRepository.InsertProjectBatched", "project.id", project.ID)
+	defer span.End()
+
+	uow := NewUnitOfWork(db, ProjectSchema)
+	uow.RegisterNew(&project)
+
+	fk := columnfieldmap.ColumnFieldPair{ColumnName: "project_id", Field: &project.ID}
+	baseLink := NewBaseLinks("TASK_RESOURCE", "TASK_ID", "RESOURCE_ID")
+	for i := range project.Tasks {
+		task := &project.Tasks[i]
+		uow.RegisterNew(task, fk)
+
+		resourcesIds := make([]int, 0, len(task.Resources))
+		for _, resource := range task.Resources {
+			resourcesIds = append(resourcesIds, resource.ID)
+		}
+		uow.RegisterLinks(baseLink.NewLinks(task.ID, resourcesIds))
+	}
+
+	if err := uow.CommitContext(ctx); err != nil {
+		return -1, err
+	}
+	return project.ID, nil
+}
+
+// ReadProject retrieves a project by ID, including its tasks and resources associated with each task,
+// sorted by key or by the given order.
+func ReadProject(db *sql.DB, projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	ctx, span := trace.Start(context.Background(), "SQLRepository.ReadProject", "project.id", projectID)
+	defer span.End()
+
+	project, err := readProject[entities.Project](ctx, db, projectTree, nil, projectID, order)
+	if err == nil && project == nil {
+		// A missing project reads as an empty one, as it always has.
+		project = &entities.Project{ID: projectID}
+	}
+	return project, err
+}
+
+// readProject runs the ReadProject join and assembles its rows with tree. The
+// project is nil when it does not exist.
+func readProject[P any](ctx context.Context, db *sql.DB, tree *assembler.Node, identity assembler.Identity, projectID int, order []orderby.OrderBy) (*P, error) {
+	sortOrder, err := orderby.Resolve(order).JoinClause("t", "r")
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " +
+		prefixedColumns("p", &entities.Project{}) + ", " +
+		prefixedColumns("t", &entities.Task{}) + ", " +
+		prefixedColumns("r", &entities.Resource{}) + `
 	FROM PROJECTS p
 		LEFT JOIN TASKS t ON p.ID = t.PROJECT_ID
 		LEFT JOIN TASK_RESOURCE tr ON t.ID = tr.TASK_ID
 		LEFT JOIN RESOURCES r ON r.ID = tr.RESOURCE_ID
 	WHERE p.ID = $1
-	`
+	ORDER BY ` + sortOrder
 
-	rows, err := db.Query(query, projectID)
+	rows, err := db.QueryContext(ctx, query, projectID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	project := &entities.Project{ID: projectID}
-	taskMap := make(map[int]*entities.Task)
-
-	var tID, rID sql.NullInt32
-	var pName, pManager, tName, tResponsible, tStatus, tPriority, rType, rName, rStatus, rSupplier sql.NullString
-	var pStartDate, tDeadline time.Time
-	var pEndDate, rAcquisitionDate sql.NullTime
-	var pBudget, rDailyCost sql.NullFloat64
-	var tEstimatedTime, tDescription sql.NullString
-	var rQuantity sql.NullInt32
-	var pDescription sql.NullString
-
-	for rows.Next() {
-		err := rows.Scan(
-			&pName, &pManager, &pStartDate, &pEndDate, &pBudget, &pDescription,
-			&tID, &tName, &tResponsible, &tDeadline, &tStatus, &tPriority, &tEstimatedTime, &tDescription,
-			&rID, &rType, &rName, &rDailyCost, &rStatus, &rSupplier, &rQuantity, &rAcquisitionDate,
-		)
-		if err != nil {
-			return nil, err
-		}
 
-		project.Name = pName.String
-		project.Manager = pManager.String
-		project.StartDate = pStartDate
-		project.EndDate = parseTimePtr(pEndDate)
-		project.Budget = parseFloatPtr(pBudget)
-		project.Description = parseStringPtr(pDescription)
-
-		if tID.Valid {
-			task, exists := taskMap[int(tID.Int32)]
-			if !exists {
-				task = &entities.Task{
-					ID:            int(tID.Int32),
-					Name:          tName.String,
-					Responsible:   parseStringPtr(tResponsible),
-					Deadline:      tDeadline,
-					Status:        tStatus.String,
-					Priority:      parseStringPtr(tPriority),
-					EstimatedTime: parseStringPtr(tEstimatedTime),
-					Description:   parseStringPtr(tDescription),
-				}
-				taskMap[int(tID.Int32)] = task
-			}
-
-			if rID.Valid {
-				resource := entities.Resource{
-					ID:              int(rID.Int32),
-					Type:            rType.String,
-					Name:            rName.String,
-					DailyCost:       parseFloatPtr(rDailyCost),
-					Status:          rStatus.String,
-					Supplier:        parseStringPtr(rSupplier),
-					Quantity:        parseIntPtr(rQuantity),
-					AcquisitionDate: parseTimePtr(rAcquisitionDate),
-				}
-				task.Resources = append(task.Resources, resource)
-			}
-		}
+	projects, err := assembler.AssembleWith[P](rows, tree, identity)
+	if err != nil || len(projects) == 0 {
+		return nil, err
 	}
 
-	for _, task := range taskMap {
-		project.Tasks = append(project.Tasks, *task)
-	}
+	return projects[0], nil
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+// projectTree maps the columns of the ReadProject join onto Project -> Tasks -> Resources.
+var projectTree = newProjectTree()
+
+func newProjectTree() *assembler.Node {
+	project := entityNode[entities.Project]()
+	task := entityNode[entities.Task]()
+	resource := entityNode[entities.Resource]()
+
+	assembler.Join(project, task, func(p *entities.Project) *[]entities.Task { return &p.Tasks })
+	assembler.Join(task, resource, func(t *entities.Task) *[]entities.Resource { return &t.Resources })
+	return project
+}
+
+// sessionProjectTree maps the same join onto SessionProject, attaching the
+// session instances of the tasks and resources.
+var sessionProjectTree = newSessionProjectTree()
+
+func newSessionProjectTree() *assembler.Node {
+	project := entityNode[entities.SessionProject]()
+	task := entityNode[entities.SessionTask]()
+	resource := entityNode[entities.Resource]()
+
+	assembler.JoinRefs(project, task, func(p *entities.SessionProject) *[]*entities.SessionTask { return &p.Tasks })
+	assembler.JoinRefs(task, resource, func(t *entities.SessionTask) *[]*entities.Resource { return &t.Resources })
+	return project
+}
+
+// entityNode maps an entity's columns, keyed by its primary key column and
+// named after its table.
+func entityNode[T any, PT EntityPtr[T]]() *assembler.Node {
+	var model T
+	columns := PT(&model).ColumnsNames()
+	pk := PT(&model).PKColNames()[0]
+
+	key := 0
+	for i, col := range columns {
+		if strings.EqualFold(col, pk) {
+			key = i
+		}
 	}
+	node := assembler.New(func(entity *T) []interface{} { return PT(entity).Fields() }, key)
+	return node.Table(PT(&model).TableName())
+}
 
-	return project, nil
+// prefixedColumns lists an entity's columns qualified by a table alias.
+func prefixedColumns(alias string, entity Entity) string {
+	columns := entity.ColumnsNames()
+	for i, col := range columns {
+		columns[i] = alias + "." + col
+	}
+	return strings.Join(columns, ", ")
 }
 
 // UpdateProject updates the details of a project by ID.
 func UpdateProject(db *sql.DB, updatedProject *entities.Project) error {
+	ctx, span := trace.Start(context.Background(), "SQLRepository.UpdateProject", "project.id", updatedProject.ID)
+	defer span.End()
+
 	repo, err := NewSQLRepository(db)
 	if err != nil {
 		panic(err)
 	}
+	repo = repo.WithContext(ctx)
 	err = repo.Update(updatedProject)
 	for _, task := range updatedProject.Tasks {
 		err = repo.Update(&task)
@@ -175,39 +207,14 @@ func UpdateProject(db *sql.DB, updatedProject *entities.Project) error {
 
 // DeleteProject deletes a project by ID.
 func DeleteProject(db *sql.DB, projectID int) error {
+	ctx, span := trace.Start(context.Background(), "SQLRepository.DeleteProject", "project.id", projectID)
+	defer span.End()
+
 	var project entities.Project
 	repo, err := NewSQLRepository(db)
 	if err != nil {
 		panic(err)
 	}
+	repo = repo.WithContext(ctx)
 	return repo.Delete(projectID, &project)
 }
-
-func parseStringPtr(input sql.NullString) *string {
-	if input.Valid {
-		return &input.String
-	}
-	return nil
-}
-
-func parseIntPtr(input sql.NullInt32) *int {
-	if input.Valid {
-		value := int(input.Int32)
-		return &value
-	}
-	return nil
-}
-
-func parseTimePtr(input sql.NullTime) *time.Time {
-	if input.Valid {
-		return &input.Time
-	}
-	return nil
-}
-
-func parseFloatPtr(input sql.NullFloat64) *float64 {
-	if input.Valid {
-		return &input.Float64
-	}
-	return nil
-}
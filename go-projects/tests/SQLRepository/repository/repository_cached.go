@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"m/tests/SQLRepository/entities"
+	"m/utils/cache"
+	"m/utils/orderby"
+	"reflect"
+	"strings"
+)
+
+// CachedSQLRepository wraps SQLRepository.Get and ReadProject with a
+// read-through cache, handing out copies of the cached values. Update, Delete and Links evict every entry read from the
+// rows they change, including rows removed by ON DELETE CASCADE in schema.
+type CachedSQLRepository struct {
+	db     *sql.DB
+	repo   *SQLRepository
+	cache  *cache.Cache
+	schema []ForeignKey
+}
+
+func NewCachedSQLRepository(db *sql.DB, c *cache.Cache, schema []ForeignKey) (*CachedSQLRepository, error) {
+	repo, err := NewSQLRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	return &CachedSQLRepository{db: db, repo: repo, cache: c, schema: schema}, nil
+}
+
+// Cache exposes the underlying cache and its counters.
+func (r *CachedSQLRepository) Cache() *cache.Cache {
+	return r.cache
+}
+
+// Get fills entity from the cache, or reads it and caches a private copy.
+func (r *CachedSQLRepository) Get(id int, entity Entity) error {
+	name := fmt.Sprintf("SQLRepository.Get/%s/%d", strings.ToLower(entity.TableName()), id)
+	if cached, exists := r.cache.Get(name); exists {
+		copyEntity(entity, cached.(Entity))
+		return nil
+	}
+
+	if err := r.repo.Get(id, entity); err != nil {
+		return err
+	}
+	snapshot := reflect.New(reflect.TypeOf(entity).Elem()).Interface().(Entity)
+	copyEntity(snapshot, entity)
+	r.cache.Set(name, snapshot, cache.NewKey(entity.TableName(), id))
+	return nil
+}
+
+func (r *CachedSQLRepository) Update(entity Entity) error {
+	err := r.repo.Update(entity)
+	if id, ok := r.repo.recValue(entity.PKFields()[0]).(int); ok {
+		r.cache.Invalidate(cache.NewKey(entity.TableName(), id))
+	}
+	return err
+}
+
+func (r *CachedSQLRepository) Delete(id int, entity Entity) error {
+	err := r.repo.Delete(id, entity)
+	r.cache.Invalidate(cache.NewKey(entity.TableName(), id))
+	r.invalidateCascades(strings.ToLower(entity.TableName()), map[string]bool{})
+	return err
+}
+
+func (r *CachedSQLRepository) Links(links Links) error {
+	err := r.repo.Links(links)
+	r.cache.Invalidate(cache.NewKey(r.masterTable(links), links.MasterId))
+	return err
+}
+
+func (r *CachedSQLRepository) InsertProject(project entities.Project) (int, error) {
+	id, err := InsertProject(r.db, project)
+	r.cache.Invalidate(cache.ProjectRows(&project)...)
+	return id, err
+}
+
+func (r *CachedSQLRepository) ReadProject(projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	name := fmt.Sprintf("SQLRepository.ReadProject/%d/%v", projectID, orderby.Resolve(order))
+	return cache.ReadThrough(r.cache, name, func() (*entities.Project, error) {
+		return ReadProject(r.db, projectID, order...)
+	}, cache.ProjectRows[entities.Project], cache.DeepCopy[*entities.Project])
+}
+
+// UpdateProject evicts the project even when the update fails, since some of
+// its statements may already have been applied.
+func (r *CachedSQLRepository) UpdateProject(project *entities.Project) error {
+	err := UpdateProject(r.db, project)
+	r.cache.Invalidate(cache.ProjectRows(project)...)
+	return err
+}
+
+func (r *CachedSQLRepository) DeleteProject(projectID int) error {
+	return r.Delete(projectID, &entities.Project{})
+}
+
+// invalidateCascades evicts whole tables whose rows may have been removed by
+// a cascading delete from table, since the removed keys are not known here.
+func (r *CachedSQLRepository) invalidateCascades(table string, visited map[string]bool) {
+	visited[table] = true
+	for _, fk := range r.schema {
+		child := strings.ToLower(fk.Table)
+		if !fk.Cascade || !strings.EqualFold(fk.RefTable, table) || visited[child] {
+			continue
+		}
+		r.cache.InvalidateTable(child)
+		r.invalidateCascades(child, visited)
+	}
+}
+
+// masterTable finds the table referenced by the master column of a link table.
+func (r *CachedSQLRepository) masterTable(links Links) string {
+	for _, fk := range r.schema {
+		if strings.EqualFold(fk.Table, links.TableName) && strings.EqualFold(fk.Column, links.MasterColName) {
+			return fk.RefTable
+		}
+	}
+	return links.TableName
+}
+
+// copyEntity copies the mapped columns of src into dst, duplicating nullable values.
+func copyEntity(dst, src Entity) {
+	srcFields := src.Fields()
+	for i, field := range dst.Fields() {
+		reflect.ValueOf(field).Elem().Set(reflect.ValueOf(cloneValue(srcFields[i])))
+	}
+}
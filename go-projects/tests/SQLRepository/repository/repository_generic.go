@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	columnfieldmap "m/tests/SQLRepository/columnFieldMap"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrDuplicateKey is returned when an insert collides with an existing primary key.
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrForeignKey is returned when a write references a row that does not exist.
+	ErrForeignKey = errors.New("foreign key violation")
+)
+
+// EntityPtr constrains a type parameter to a pointer to T implementing Entity.
+type EntityPtr[T any] interface {
+	*T
+	Entity
+}
+
+// Repository is the generic contract for reading and writing a single entity type.
+type Repository[T any] interface {
+	Get(id int) (*T, error)
+	List() ([]T, error)
+	Insert(entity *T, fks ...columnfieldmap.ColumnFieldPair) error
+	Update(entity *T) error
+	Delete(id int) error
+	Links(links Links) error
+	SelectLinks(links Links) ([]int, error)
+}
+
+// PostgresRepository implements Repository on top of SQLRepository.
+type PostgresRepository[T any, PT EntityPtr[T]] struct {
+	repo *SQLRepository
+}
+
+func NewPostgresRepository[T any, PT EntityPtr[T]](db *sql.DB) (*PostgresRepository[T, PT], error) {
+	repo, err := NewSQLRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresRepository[T, PT]{repo: repo}, nil
+}
+
+func (r *PostgresRepository[T, PT]) Get(id int) (*T, error) {
+	entity := new(T)
+	if err := r.repo.Get(id, PT(entity)); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *PostgresRepository[T, PT]) List() ([]T, error) {
+	var model T
+	entity := PT(&model)
+	query := "SELECT " + strings.Join(entity.ColumnsNames(), ", ") + " FROM " + entity.TableName()
+
+	rows, err := r.repo.db.QueryContext(r.repo.context(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []T
+	for rows.Next() {
+		var item T
+		if err := rows.Scan(PT(&item).Fields()...); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository[T, PT]) Insert(entity *T, fks ...columnfieldmap.ColumnFieldPair) error {
+	if len(fks) == 0 {
+		return translateError(r.repo.Insert(PT(entity)))
+	}
+	return translateError(r.repo.InsertWithFK(PT(entity), fks))
+}
+
+func (r *PostgresRepository[T, PT]) Update(entity *T) error {
+	return translateError(r.repo.Update(PT(entity)))
+}
+
+func (r *PostgresRepository[T, PT]) Delete(id int) error {
+	var entity T
+	return translateError(r.repo.Delete(id, PT(&entity)))
+}
+
+func (r *PostgresRepository[T, PT]) Links(links Links) error {
+	return translateError(r.repo.Links(links))
+}
+
+func (r *PostgresRepository[T, PT]) SelectLinks(links Links) ([]int, error) {
+	return r.repo.SelectLinks(links)
+}
+
+// translateError maps PostgreSQL constraint violations onto the repository
+// sentinel errors, so callers behave the same against every implementation.
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		return fmt.Errorf("%w: %w", ErrDuplicateKey, err)
+	case "foreign_key_violation":
+		return fmt.Errorf("%w: %w", ErrForeignKey, err)
+	}
+	return err
+}
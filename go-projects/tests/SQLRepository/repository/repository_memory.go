@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	columnfieldmap "m/tests/SQLRepository/columnFieldMap"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ForeignKey describes a column that references the "id" column of another table.
+type ForeignKey struct {
+	Table    string
+	Column   string
+	RefTable string
+	Cascade  bool // ON DELETE CASCADE; otherwise the delete is restricted
+}
+
+// ProjectSchema mirrors the foreign keys declared in database/schema.sql.
+var ProjectSchema = []ForeignKey{
+	{Table: "tasks", Column: "project_id", RefTable: "projects", Cascade: true},
+	{Table: "task_resource", Column: "task_id", RefTable: "tasks", Cascade: true},
+	{Table: "task_resource", Column: "resource_id", RefTable: "resources", Cascade: true},
+}
+
+type memoryRow map[string]interface{}
+
+type memoryTable struct {
+	keys []string
+	rows map[string]memoryRow
+}
+
+// MemoryStore holds the tables shared by every MemoryRepository created on it,
+// so keys and cascades are enforced across entity types.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	schema []ForeignKey
+	tables map[string]*memoryTable
+}
+
+func NewMemoryStore(schema []ForeignKey) *MemoryStore {
+	normalized := make([]ForeignKey, len(schema))
+	for i, fk := range schema {
+		normalized[i] = ForeignKey{
+			Table:    strings.ToLower(fk.Table),
+			Column:   strings.ToLower(fk.Column),
+			RefTable: strings.ToLower(fk.RefTable),
+			Cascade:  fk.Cascade,
+		}
+	}
+	return &MemoryStore{schema: normalized, tables: make(map[string]*memoryTable)}
+}
+
+func (s *MemoryStore) table(name string) *memoryTable {
+	name = strings.ToLower(name)
+	t, ok := s.tables[name]
+	if !ok {
+		t = &memoryTable{rows: make(map[string]memoryRow)}
+		s.tables[name] = t
+	}
+	return t
+}
+
+// lookup returns a table without creating it, for use under the read lock.
+func (s *MemoryStore) lookup(name string) *memoryTable {
+	if t, ok := s.tables[strings.ToLower(name)]; ok {
+		return t
+	}
+	return &memoryTable{}
+}
+
+func (s *MemoryStore) insert(table string, pkCols []string, row memoryRow) error {
+	table = strings.ToLower(table)
+	key := rowKey(row, pkCols)
+	t := s.table(table)
+	if _, exists := t.rows[key]; exists {
+		return fmt.Errorf("%w: %s(%s)", ErrDuplicateKey, table, key)
+	}
+	for _, fk := range s.schema {
+		if fk.Table != table || row[fk.Column] == nil {
+			continue
+		}
+		ref := fmt.Sprint(row[fk.Column])
+		if _, exists := s.table(fk.RefTable).rows[ref]; !exists {
+			return fmt.Errorf("%w: %s.%s = %s", ErrForeignKey, table, fk.Column, ref)
+		}
+	}
+	t.keys = append(t.keys, key)
+	t.rows[key] = row
+	return nil
+}
+
+// delete removes a row and everything that cascades from it. Restricted
+// references are checked before anything is removed.
+func (s *MemoryStore) delete(table, key string) error {
+	type target struct{ table, key string }
+	var targets []target
+	visited := make(map[target]bool)
+
+	var collect func(t target) error
+	collect = func(t target) error {
+		if visited[t] {
+			return nil
+		}
+		visited[t] = true
+		targets = append(targets, t)
+		for _, fk := range s.schema {
+			if fk.RefTable != t.table {
+				continue
+			}
+			child := s.table(fk.Table)
+			for _, childKey := range child.keys {
+				if fmt.Sprint(child.rows[childKey][fk.Column]) != t.key {
+					continue
+				}
+				if !fk.Cascade {
+					return fmt.Errorf("%w: %s(%s) is referenced by %s", ErrForeignKey, t.table, t.key, fk.Table)
+				}
+				if err := collect(target{fk.Table, childKey}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	table = strings.ToLower(table)
+	if _, exists := s.table(table).rows[key]; !exists {
+		return nil
+	}
+	if err := collect(target{table, key}); err != nil {
+		return err
+	}
+	for _, t := range targets {
+		s.table(t.table).remove(t.key)
+	}
+	return nil
+}
+
+func (t *memoryTable) remove(key string) {
+	delete(t.rows, key)
+	for i, k := range t.keys {
+		if k == key {
+			t.keys = append(t.keys[:i], t.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// MemoryRepository implements Repository without a database, for unit tests.
+type MemoryRepository[T any, PT EntityPtr[T]] struct {
+	store *MemoryStore
+	table string
+}
+
+func NewMemoryRepository[T any, PT EntityPtr[T]](store *MemoryStore) *MemoryRepository[T, PT] {
+	var model T
+	return &MemoryRepository[T, PT]{store: store, table: strings.ToLower(PT(&model).TableName())}
+}
+
+func (r *MemoryRepository[T, PT]) Get(id int) (*T, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	row, exists := r.store.lookup(r.table).rows[fmt.Sprint(id)]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	entity := new(T)
+	loadRow(PT(entity), row)
+	return entity, nil
+}
+
+func (r *MemoryRepository[T, PT]) List() ([]T, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	t := r.store.lookup(r.table)
+	var list []T
+	for _, key := range t.keys {
+		var item T
+		loadRow(PT(&item), t.rows[key])
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+func (r *MemoryRepository[T, PT]) Insert(entity *T, fks ...columnfieldmap.ColumnFieldPair) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	row := storeRow(PT(entity))
+	for _, fk := range fks {
+		row[strings.ToLower(fk.ColumnName)] = cloneValue(fk.Field)
+	}
+	return r.store.insert(r.table, PT(entity).PKColNames(), row)
+}
+
+func (r *MemoryRepository[T, PT]) Update(entity *T) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.store.table(r.table)
+	key := rowKey(storeRow(PT(entity)), PT(entity).PKColNames())
+	existing, exists := t.rows[key]
+	if !exists {
+		return nil
+	}
+	for col, value := range storeRow(PT(entity)) {
+		existing[col] = value
+	}
+	return nil
+}
+
+func (r *MemoryRepository[T, PT]) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.delete(r.table, fmt.Sprint(id))
+}
+
+func (r *MemoryRepository[T, PT]) Links(links Links) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	master := strings.ToLower(links.MasterColName)
+	link := strings.ToLower(links.LinkColName)
+	t := r.store.table(links.TableName)
+	for _, key := range append([]string(nil), t.keys...) {
+		if fmt.Sprint(t.rows[key][master]) == fmt.Sprint(links.MasterId) {
+			t.remove(key)
+		}
+	}
+
+	for _, id := range links.LinksIds {
+		row := memoryRow{master: links.MasterId, link: id}
+		if err := r.store.insert(links.TableName, []string{master, link}, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository[T, PT]) SelectLinks(links Links) ([]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	master := strings.ToLower(links.MasterColName)
+	link := strings.ToLower(links.LinkColName)
+	t := r.store.lookup(links.TableName)
+	var ids []int
+	for _, key := range t.keys {
+		row := t.rows[key]
+		if fmt.Sprint(row[master]) == fmt.Sprint(links.MasterId) {
+			ids = append(ids, row[link].(int))
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func rowKey(row memoryRow, pkCols []string) string {
+	parts := make([]string, len(pkCols))
+	for i, col := range pkCols {
+		parts[i] = fmt.Sprint(row[strings.ToLower(col)])
+	}
+	return strings.Join(parts, "/")
+}
+
+// storeRow copies the mapped columns of an entity, so later changes made by
+// the caller do not leak into the store.
+func storeRow(entity Entity) memoryRow {
+	columns := entity.ColumnsNames()
+	fields := entity.Fields()
+	row := make(memoryRow, len(columns))
+	for i, col := range columns {
+		row[strings.ToLower(col)] = cloneValue(fields[i])
+	}
+	return row
+}
+
+func loadRow(entity Entity, row memoryRow) {
+	columns := entity.ColumnsNames()
+	fields := entity.Fields()
+	for i, col := range columns {
+		field := reflect.ValueOf(fields[i]).Elem()
+		value, exists := row[strings.ToLower(col)]
+		if !exists || value == nil {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		field.Set(reflect.ValueOf(cloneValue(&value)))
+	}
+}
+
+// cloneValue dereferences a field pointer and deep-copies nullable values.
+func cloneValue(field interface{}) interface{} {
+	value := reflect.ValueOf(field).Elem()
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		copied := reflect.New(value.Type().Elem())
+		copied.Elem().Set(value.Elem())
+		return copied.Interface()
+	}
+	return value.Interface()
+}
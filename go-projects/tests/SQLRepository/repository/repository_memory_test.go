@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	columnfieldmap "m/tests/SQLRepository/columnFieldMap"
+	"m/tests/SQLRepository/entities"
+	"testing"
+	"time"
+)
+
+func newMemoryFixture(t *testing.T) (Repository[entities.Project], Repository[entities.Task], Repository[entities.Resource]) {
+	store := NewMemoryStore(ProjectSchema)
+	projects := NewMemoryRepository[entities.Project](store)
+	tasks := NewMemoryRepository[entities.Task](store)
+	resources := NewMemoryRepository[entities.Resource](store)
+
+	for _, id := range []int{1, 2} {
+		if err := resources.Insert(&entities.Resource{ID: id, Type: "labor", Name: "Resource", Status: "available"}); err != nil {
+			t.Fatalf("Failed to insert resource: %v", err)
+		}
+	}
+
+	project := entities.Project{ID: 10, Name: "Project", Manager: "Manager", StartDate: time.Now()}
+	if err := projects.Insert(&project); err != nil {
+		t.Fatalf("Failed to insert project: %v", err)
+	}
+
+	fk := columnfieldmap.ColumnFieldPair{ColumnName: "project_id", Field: &project.ID}
+	if err := tasks.Insert(&entities.Task{ID: 100, Name: "Task", Status: "pending"}, fk); err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+	if err := tasks.Links(NewBaseLinks("TASK_RESOURCE", "TASK_ID", "RESOURCE_ID").NewLinks(100, []int{1, 2})); err != nil {
+		t.Fatalf("Failed to link resources: %v", err)
+	}
+
+	return projects, tasks, resources
+}
+
+func TestMemoryRepositoryGetReturnsCopy(t *testing.T) {
+	projects, _, _ := newMemoryFixture(t)
+
+	project, err := projects.Get(10)
+	if err != nil {
+		t.Fatalf("Failed to get project: %v", err)
+	}
+	project.Name = "changed"
+
+	stored, _ := projects.Get(10)
+	if stored.Name != "Project" {
+		t.Errorf("Stored project was modified through a returned copy: %q", stored.Name)
+	}
+
+	if _, err := projects.Get(11); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for a missing project, got %v", err)
+	}
+}
+
+func TestMemoryRepositoryKeys(t *testing.T) {
+	projects, tasks, _ := newMemoryFixture(t)
+
+	err := projects.Insert(&entities.Project{ID: 10, Name: "Duplicate"})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Expected ErrDuplicateKey, got %v", err)
+	}
+
+	missing := 99
+	fk := columnfieldmap.ColumnFieldPair{ColumnName: "project_id", Field: &missing}
+	err = tasks.Insert(&entities.Task{ID: 101, Name: "Orphan"}, fk)
+	if !errors.Is(err, ErrForeignKey) {
+		t.Errorf("Expected ErrForeignKey for a task without project, got %v", err)
+	}
+
+	err = tasks.Links(NewBaseLinks("TASK_RESOURCE", "TASK_ID", "RESOURCE_ID").NewLinks(100, []int{3}))
+	if !errors.Is(err, ErrForeignKey) {
+		t.Errorf("Expected ErrForeignKey for a link to a missing resource, got %v", err)
+	}
+}
+
+func TestMemoryRepositoryCascades(t *testing.T) {
+	projects, tasks, resources := newMemoryFixture(t)
+	links := NewBaseLinks("TASK_RESOURCE", "TASK_ID", "RESOURCE_ID").NewSelectLinks(100)
+
+	if err := resources.Delete(1); err != nil {
+		t.Fatalf("Failed to delete resource: %v", err)
+	}
+	ids, _ := tasks.SelectLinks(links)
+	if len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("Expected only resource 2 to remain linked, got %v", ids)
+	}
+
+	if err := projects.Delete(10); err != nil {
+		t.Fatalf("Failed to delete project: %v", err)
+	}
+	if _, err := tasks.Get(100); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected task to be deleted with its project, got %v", err)
+	}
+	if ids, _ := tasks.SelectLinks(links); len(ids) != 0 {
+		t.Errorf("Expected links to be deleted with the task, got %v", ids)
+	}
+
+	remaining, _ := resources.List()
+	if len(remaining) != 1 || remaining[0].ID != 2 {
+		t.Errorf("Expected resource 2 to survive the cascade, got %v", remaining)
+	}
+}
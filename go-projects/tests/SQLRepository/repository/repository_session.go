@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"m/tests/SQLRepository/entities"
+	"m/utils/assembler"
+	"m/utils/identitymap"
+	"m/utils/orderby"
+	"strings"
+)
+
+// Session reads through an identity map: each row is materialised once, and
+// every read of the session returns the same instance for it. Resources shared
+// by many tasks are therefore loaded only once per session.
+type Session struct {
+	db       *sql.DB
+	identity *identitymap.Map
+}
+
+func NewSession(db *sql.DB) *Session {
+	return &Session{db: db, identity: identitymap.New()}
+}
+
+// Identity exposes the session identity map.
+func (s *Session) Identity() *identitymap.Map {
+	return s.identity
+}
+
+// ReadProject reads a project like the package-level ReadProject. Its tasks and
+// resources are the instances already loaded by the session, or are added to
+// it, so they are the ones SessionGet and SessionList return.
+func (s *Session) ReadProject(projectID int, order ...orderby.OrderBy) (*entities.SessionProject, error) {
+	project, err := readProject[entities.SessionProject](context.Background(), s.db, sessionProjectTree, s.identity, projectID, order)
+	if err == nil && project == nil {
+		project = &entities.SessionProject{Project: entities.Project{ID: projectID}}
+	}
+	return project, err
+}
+
+// SessionGet reads an entity by ID through the session identity map. The
+// session holds projects and tasks as SessionProject and SessionTask, so read
+// them as those types.
+func SessionGet[T any, PT EntityPtr[T]](s *Session, id int) (*T, error) {
+	var model T
+	if entity, exists := s.identity.Lookup(PT(&model).TableName(), id); exists {
+		found, ok := entity.(*T)
+		if !ok {
+			return nil, fmt.Errorf("session holds %s rows as %T", PT(&model).TableName(), entity)
+		}
+		return found, nil
+	}
+
+	found, err := sessionSelect[T, PT](s, " WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+// SessionList reads every row of an entity's table, ordered by primary key,
+// through the session identity map.
+func SessionList[T any, PT EntityPtr[T]](s *Session) ([]*T, error) {
+	var model T
+	return sessionSelect[T, PT](s, " ORDER BY "+strings.Join(PT(&model).PKColNames(), ", "))
+}
+
+func sessionSelect[T any, PT EntityPtr[T]](s *Session, clause string, args ...interface{}) ([]*T, error) {
+	var model T
+	entity := PT(&model)
+	query := "SELECT " + strings.Join(entity.ColumnsNames(), ", ") + " FROM " + entity.TableName() + clause
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return assembler.AssembleWith[T](rows, entityNode[T, PT](), s.identity)
+}
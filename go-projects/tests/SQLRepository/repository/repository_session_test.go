@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"m/tests/SQLRepository/entities"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sessionDrivers numbers the registered sessionDrivers, whose names must be
+// unique when the tests run more than once.
+var sessionDrivers int
+
+// sessionDriver answers the queries of a Session from fixed rows: the
+// ReadProject join, and the RESOURCES table filtered by the ID argument.
+type sessionDriver struct {
+	join      [][]driver.Value
+	resources [][]driver.Value
+}
+
+func (d *sessionDriver) Open(string) (driver.Conn, error) { return &sessionConn{d}, nil }
+
+type sessionConn struct{ d *sessionDriver }
+
+func (c *sessionConn) Prepare(query string) (driver.Stmt, error) {
+	return &sessionStmt{d: c.d, query: query}, nil
+}
+func (c *sessionConn) Close() error              { return nil }
+func (c *sessionConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type sessionStmt struct {
+	d     *sessionDriver
+	query string
+}
+
+func (s *sessionStmt) Close() error  { return nil }
+func (s *sessionStmt) NumInput() int { return -1 }
+func (s *sessionStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+func (s *sessionStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "FROM PROJECTS p") {
+		return &sessionRows{columns: make([]string, 23), rows: s.d.join}, nil
+	}
+	rows := s.d.resources
+	if len(args) == 1 {
+		rows = nil
+		for _, row := range s.d.resources {
+			if row[0] == args[0] {
+				rows = append(rows, row)
+			}
+		}
+	}
+	return &sessionRows{columns: make([]string, 8), rows: rows}, nil
+}
+
+type sessionRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *sessionRows) Columns() []string { return r.columns }
+func (r *sessionRows) Close() error      { return nil }
+func (r *sessionRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSessionSharesInstances(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	project := []driver.Value{int64(10), "Project", "Manager", start, nil, nil, nil}
+	task := func(id int64) []driver.Value {
+		return []driver.Value{id, "Task", nil, start, "pending", nil, nil, nil}
+	}
+	resource := func(id int64) []driver.Value {
+		return []driver.Value{id, "labor", "Resource", nil, "available", nil, nil, nil}
+	}
+	row := func(parts ...[]driver.Value) []driver.Value {
+		var values []driver.Value
+		for _, part := range parts {
+			values = append(values, part...)
+		}
+		return values
+	}
+
+	sessionDrivers++
+	name := fmt.Sprintf("%s/%d", t.Name(), sessionDrivers)
+	sql.Register(name, &sessionDriver{
+		join: [][]driver.Value{
+			row(project, task(100), resource(1)),
+			row(project, task(100), resource(2)),
+			row(project, task(101), resource(1)),
+		},
+		resources: [][]driver.Value{resource(1), resource(2)},
+	})
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	session := NewSession(db)
+
+	got, err := SessionGet[entities.Resource](session, 1)
+	if err != nil {
+		t.Fatalf("SessionGet failed: %v", err)
+	}
+	read, err := session.ReadProject(10)
+	if err != nil {
+		t.Fatalf("ReadProject failed: %v", err)
+	}
+	listed, err := SessionList[entities.Resource](session)
+	if err != nil {
+		t.Fatalf("SessionList failed: %v", err)
+	}
+
+	if len(read.Tasks) != 2 || len(read.Tasks[0].Resources) != 2 || len(read.Tasks[1].Resources) != 1 {
+		t.Fatalf("Unexpected project: %+v", read)
+	}
+	if read.Tasks[0].Resources[0] != got || read.Tasks[1].Resources[0] != got || listed[0] != got {
+		t.Errorf("Expected resource 1 to be the same instance in Get, ReadProject and List")
+	}
+	if read.Tasks[0].Resources[1] != listed[1] {
+		t.Errorf("Expected resource 2 to be the same instance in ReadProject and List")
+	}
+
+	again, err := session.ReadProject(10)
+	if err != nil {
+		t.Fatalf("ReadProject failed: %v", err)
+	}
+	if again != read || again.Tasks[0] != read.Tasks[0] {
+		t.Errorf("Expected a second read to return the same project and tasks")
+	}
+	task100, err := SessionGet[entities.SessionTask](session, 100)
+	if err != nil || task100 != read.Tasks[0] {
+		t.Errorf("Expected SessionGet to return the task of ReadProject, got %v, %v", task100, err)
+	}
+	if _, err := SessionGet[entities.Task](session, 100); err == nil {
+		t.Errorf("Expected an error reading a SessionTask as a Task")
+	}
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	columnfieldmap "m/tests/SQLRepository/columnFieldMap"
+	"m/utils/trace"
 	"reflect"
 	"strconv"
 	"strings"
@@ -17,19 +19,44 @@ type Entity interface {
 	PKFields() []interface{}
 }
 
+// executor is the part of *sql.DB and *sql.Tx used by SQLRepository.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
 // SQLRepository is the concrete implementation of Repository for SQL databases
 type SQLRepository struct {
-	db *sql.DB
+	db  executor
+	ctx context.Context
 }
 
 func NewSQLRepository(db *sql.DB) (*SQLRepository, error) {
 	return &SQLRepository{db: db}, nil
 }
 
+// WithContext returns a repository whose statements run with ctx, and whose
+// spans are children of the span ctx carries.
+func (repo *SQLRepository) WithContext(ctx context.Context) *SQLRepository {
+	return &SQLRepository{db: repo.db, ctx: ctx}
+}
+
+func (repo *SQLRepository) context() context.Context {
+	if repo.ctx == nil {
+		return context.Background()
+	}
+	return repo.ctx
+}
+
 func (repo *SQLRepository) Get(id int, entity Entity) error {
+	ctx, span := trace.Start(repo.context(), "SQLRepository.Get", "table", entity.TableName())
+	defer span.End()
+
 	fields := strings.Join(entity.ColumnsNames(), ", ")
 	query := "SELECT " + fields + " FROM " + entity.TableName() + " WHERE id = $1"
-	row := repo.db.QueryRow(query, id)
+	row := repo.db.QueryRowContext(ctx, query, id)
 	err := row.Scan(entity.Fields()...)
 	if err != nil {
 		return err
@@ -38,27 +65,36 @@ func (repo *SQLRepository) Get(id int, entity Entity) error {
 }
 
 func (repo *SQLRepository) Add(entity Entity) (int, error) {
+	ctx, span := trace.Start(repo.context(), "SQLRepository.Add", "table", entity.TableName())
+	defer span.End()
+
 	cols, values := repo.prepareFieldsAndValuesForAdd(entity)
 	placeholders := repo.generatePlaceholders(len(values))
 
 	query := "INSERT INTO " + entity.TableName() + " (" + cols + ") VALUES (" + placeholders + ") RETURNING IDENTIFIER"
 
 	id := -1
-	err := repo.db.QueryRow(query, values...).Scan(&id)
+	err := repo.db.QueryRowContext(ctx, query, values...).Scan(&id)
 	return id, err
 }
 
 func (repo *SQLRepository) Insert(entity Entity) error {
+	ctx, span := trace.Start(repo.context(), "SQLRepository.Insert", "table", entity.TableName())
+	defer span.End()
+
 	cols, values := repo.prepareFieldsAndValuesForInsert(entity)
 	placeholders := repo.generatePlaceholders(len(values))
 
 	query := "INSERT INTO " + entity.TableName() + " (" + cols + ") VALUES (" + placeholders + ")"
 
-	_, err := repo.db.Exec(query, values...)
+	_, err := repo.db.ExecContext(ctx, query, values...)
 	return err
 }
 
 func (repo *SQLRepository) InsertWithFK(entity Entity, fks []columnfieldmap.ColumnFieldPair) error {
+	ctx, span := trace.Start(repo.context(), "SQLRepository.InsertWithFK", "table", entity.TableName())
+	defer span.End()
+
 	cols, values := repo.prepareFieldsAndValuesForInsert(entity)
 
 	for _, fk := range fks {
@@ -70,30 +106,39 @@ func (repo *SQLRepository) InsertWithFK(entity Entity, fks []columnfieldmap.Colu
 
 	query := "INSERT INTO " + entity.TableName() + " (" + cols + ") VALUES (" + placeholders + ")"
 
-	_, err := repo.db.Exec(query, values...)
+	_, err := repo.db.ExecContext(ctx, query, values...)
 	return err
 }
 
 func (repo *SQLRepository) Update(entity Entity) error {
+	ctx, span := trace.Start(repo.context(), "SQLRepository.Update", "table", entity.TableName())
+	defer span.End()
+
 	fields, values := repo.prepareFieldsAndValuesForUpdate(entity)
 	conditional, condValues := repo.buildConditional(entity, len(values)+1)
 
 	query := "UPDATE " + entity.TableName() + " SET " + fields + " WHERE " + conditional
 
-	_, err := repo.db.Exec(query, append(values, condValues...)...)
+	_, err := repo.db.ExecContext(ctx, query, append(values, condValues...)...)
 	return err
 }
 
 func (repo *SQLRepository) Delete(id int, entity Entity) error {
+	ctx, span := trace.Start(repo.context(), "SQLRepository.Delete", "table", entity.TableName())
+	defer span.End()
+
 	query := "DELETE FROM " + entity.TableName() + " WHERE id = $1"
-	_, err := repo.db.Exec(query, id)
+	_, err := repo.db.ExecContext(ctx, query, id)
 	return err
 }
 
 func (repo *SQLRepository) Links(links Links) error {
+	ctx, span := trace.Start(repo.context(), "SQLRepository.Links", "table", links.TableName)
+	defer span.End()
+
 	del := "DELETE FROM " + links.TableName + " WHERE " + links.MasterColName + " = $1"
 
-	_, err := repo.db.Exec(del, links.MasterId)
+	_, err := repo.db.ExecContext(ctx, del, links.MasterId)
 	if err != nil {
 		return err
 	}
@@ -101,7 +146,7 @@ func (repo *SQLRepository) Links(links Links) error {
 	for _, link := range links.LinksIds {
 		insert := "INSERT INTO " + links.TableName + " (" + links.MasterColName + ", " + links.LinkColName + ") VALUES ($1, $2)"
 
-		_, err := repo.db.Exec(insert, links.MasterId, link)
+		_, err := repo.db.ExecContext(ctx, insert, links.MasterId, link)
 		if err != nil {
 			return err
 		}
@@ -109,6 +154,29 @@ func (repo *SQLRepository) Links(links Links) error {
 	return nil
 }
 
+func (repo *SQLRepository) SelectLinks(links Links) ([]int, error) {
+	ctx, span := trace.Start(repo.context(), "SQLRepository.SelectLinks", "table", links.TableName)
+	defer span.End()
+
+	query := "SELECT " + links.LinkColName + " FROM " + links.TableName + " WHERE " + links.MasterColName + " = $1 ORDER BY " + links.LinkColName
+
+	rows, err := repo.db.QueryContext(ctx, query, links.MasterId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func (repo *SQLRepository) prepareFieldsAndValuesForAdd(entity Entity) (string, []interface{}) {
 	columns := entity.ColumnsNames()
 	fields := entity.Fields()
@@ -0,0 +1,354 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	columnfieldmap "m/tests/SQLRepository/columnFieldMap"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// maxParams is the PostgreSQL limit of bind parameters in a single statement.
+const maxParams = 65535
+
+type pendingEntity struct {
+	entity Entity
+	fks    []columnfieldmap.ColumnFieldPair
+}
+
+// UnitOfWork collects new, dirty and deleted entities and link changes, and
+// writes them in one transaction ordered by the foreign keys of its schema:
+// deletes run first, children first, so that a row deleted and inserted again
+// under the same key does not collide; inserts and links then run parents
+// first, and updates last.
+type UnitOfWork struct {
+	db      *sql.DB
+	schema  []ForeignKey
+	created []pendingEntity
+	dirty   []Entity
+	deleted []Entity
+	links   []Links
+}
+
+func NewUnitOfWork(db *sql.DB, schema []ForeignKey) *UnitOfWork {
+	return &UnitOfWork{db: db, schema: schema}
+}
+
+// RegisterNew schedules an insert. Field values are read when the unit is committed.
+func (u *UnitOfWork) RegisterNew(entity Entity, fks ...columnfieldmap.ColumnFieldPair) {
+	u.created = append(u.created, pendingEntity{entity: entity, fks: fks})
+}
+
+// RegisterDirty schedules an update. Entities already registered as new are
+// inserted with their latest values instead.
+func (u *UnitOfWork) RegisterDirty(entity Entity) {
+	if u.isNew(entity) || containsEntity(u.dirty, entity) {
+		return
+	}
+	u.dirty = append(u.dirty, entity)
+}
+
+// RegisterDeleted schedules a delete. Entities registered as new in this unit
+// are simply dropped from it.
+func (u *UnitOfWork) RegisterDeleted(entity Entity) {
+	for i, pending := range u.created {
+		if pending.entity == entity {
+			u.created = append(u.created[:i], u.created[i+1:]...)
+			return
+		}
+	}
+	for i, dirty := range u.dirty {
+		if dirty == entity {
+			u.dirty = append(u.dirty[:i], u.dirty[i+1:]...)
+			break
+		}
+	}
+	if !containsEntity(u.deleted, entity) {
+		u.deleted = append(u.deleted, entity)
+	}
+}
+
+// RegisterLinks schedules the replacement of the links of a master row.
+func (u *UnitOfWork) RegisterLinks(links Links) {
+	u.links = append(u.links, links)
+}
+
+func (u *UnitOfWork) isNew(entity Entity) bool {
+	for _, pending := range u.created {
+		if pending.entity == entity {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEntity(list []Entity, entity Entity) bool {
+	for _, item := range list {
+		if item == entity {
+			return true
+		}
+	}
+	return false
+}
+
+// Commit flushes every registered change in a single transaction. On success
+// the unit is emptied; on failure the transaction is rolled back and the
+// registrations are kept.
+func (u *UnitOfWork) Commit() error {
+	return u.CommitContext(context.Background())
+}
+
+// CommitContext is Commit running the transaction with ctx.
+func (u *UnitOfWork) CommitContext(ctx context.Context) error {
+	order, err := u.tableOrder()
+	if err != nil {
+		return err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	repo := &SQLRepository{db: tx, ctx: ctx}
+
+	if err := u.flush(repo, order); err != nil {
+		tx.Rollback()
+		return translateError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	u.created, u.dirty, u.deleted, u.links = nil, nil, nil, nil
+	return nil
+}
+
+func (u *UnitOfWork) flush(repo *SQLRepository, order []string) error {
+	for i := len(order) - 1; i >= 0; i-- {
+		if err := u.deleteTable(repo, order[i]); err != nil {
+			return err
+		}
+	}
+	for _, table := range order {
+		if err := u.insertTable(repo, table); err != nil {
+			return err
+		}
+		if err := u.linkTable(repo, table); err != nil {
+			return err
+		}
+	}
+	for _, table := range order {
+		if err := u.updateTable(repo, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableOrder sorts every table touched by the unit so that referenced tables
+// come before the tables that reference them.
+func (u *UnitOfWork) tableOrder() ([]string, error) {
+	tables := make(map[string]bool)
+	for _, pending := range u.created {
+		tables[strings.ToLower(pending.entity.TableName())] = true
+	}
+	for _, entity := range append(append([]Entity(nil), u.dirty...), u.deleted...) {
+		tables[strings.ToLower(entity.TableName())] = true
+	}
+	for _, links := range u.links {
+		tables[strings.ToLower(links.TableName)] = true
+	}
+
+	dependsOn := make(map[string]map[string]bool)
+	for _, fk := range u.schema {
+		table, ref := strings.ToLower(fk.Table), strings.ToLower(fk.RefTable)
+		if dependsOn[table] == nil {
+			dependsOn[table] = make(map[string]bool)
+		}
+		dependsOn[table][ref] = true
+		tables[table] = true
+		tables[ref] = true
+	}
+
+	var order []string
+	done := make(map[string]bool)
+	for len(done) < len(tables) {
+		var ready []string
+		for table := range tables {
+			if done[table] {
+				continue
+			}
+			blocked := false
+			for ref := range dependsOn[table] {
+				if !done[ref] && ref != table {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				ready = append(ready, table)
+			}
+		}
+		if len(ready) == 0 {
+			return nil, fmt.Errorf("unit of work: foreign keys form a cycle")
+		}
+		sort.Strings(ready)
+		for _, table := range ready {
+			done[table] = true
+		}
+		order = append(order, ready...)
+	}
+	return order, nil
+}
+
+// insertTable writes the new entities of a table with multi-row INSERT
+// statements, grouped by column list.
+func (u *UnitOfWork) insertTable(repo *SQLRepository, table string) error {
+	groups := make(map[string][][]interface{})
+	var columnLists []string
+	for _, pending := range u.created {
+		if !strings.EqualFold(pending.entity.TableName(), table) {
+			continue
+		}
+		cols, values := repo.prepareFieldsAndValuesForInsert(pending.entity)
+		for _, fk := range pending.fks {
+			cols += ", " + fk.ColumnName
+			values = append(values, repo.recValue(fk.Field))
+		}
+		if _, exists := groups[cols]; !exists {
+			columnLists = append(columnLists, cols)
+		}
+		groups[cols] = append(groups[cols], values)
+	}
+
+	for _, cols := range columnLists {
+		err := execBatched(repo, "INSERT INTO "+table+" ("+cols+") VALUES ", groups[cols])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkTable replaces the links of every registered master row of a link table:
+// one DELETE for all masters and multi-row INSERTs for the new pairs.
+func (u *UnitOfWork) linkTable(repo *SQLRepository, table string) error {
+	var masters []int
+	var rows [][]interface{}
+	var base BaseLinks
+	for _, links := range u.links {
+		if !strings.EqualFold(links.TableName, table) {
+			continue
+		}
+		if len(masters) > 0 && (base.MasterColName != links.MasterColName || base.LinkColName != links.LinkColName) {
+			return fmt.Errorf("unit of work: conflicting link columns for %s", table)
+		}
+		base = links.BaseLinks
+		masters = append(masters, links.MasterId)
+		for _, id := range links.LinksIds {
+			rows = append(rows, []interface{}{links.MasterId, id})
+		}
+	}
+	if len(masters) == 0 {
+		return nil
+	}
+
+	del := "DELETE FROM " + table + " WHERE " + base.MasterColName + " = ANY($1)"
+	if _, err := repo.db.ExecContext(repo.context(), del, pq.Array(masters)); err != nil {
+		return err
+	}
+	return execBatched(repo, "INSERT INTO "+table+" ("+base.MasterColName+", "+base.LinkColName+") VALUES ", rows)
+}
+
+// updateTable prepares one UPDATE per table and runs it for each dirty entity.
+func (u *UnitOfWork) updateTable(repo *SQLRepository, table string) error {
+	var stmt *sql.Stmt
+	defer func() {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}()
+
+	for _, entity := range u.dirty {
+		if !strings.EqualFold(entity.TableName(), table) {
+			continue
+		}
+		fields, values := repo.prepareFieldsAndValuesForUpdate(entity)
+		conditional, condValues := repo.buildConditional(entity, len(values)+1)
+		if stmt == nil {
+			var err error
+			stmt, err = repo.db.PrepareContext(repo.context(), "UPDATE "+table+" SET "+fields+" WHERE "+conditional)
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := stmt.ExecContext(repo.context(), append(values, condValues...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTable removes the deleted entities of a table. Single-column keys are
+// deleted with one statement; composite keys fall back to one per row.
+func (u *UnitOfWork) deleteTable(repo *SQLRepository, table string) error {
+	var keys []interface{}
+	var keyCol string
+	for _, entity := range u.deleted {
+		if !strings.EqualFold(entity.TableName(), table) {
+			continue
+		}
+		if len(entity.PKColNames()) != 1 {
+			conditional, values := repo.buildConditional(entity, 1)
+			if _, err := repo.db.ExecContext(repo.context(), "DELETE FROM "+table+" WHERE "+conditional, values...); err != nil {
+				return err
+			}
+			continue
+		}
+		keyCol = entity.PKColNames()[0]
+		keys = append(keys, repo.recValue(entity.PKFields()[0]))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := repo.db.ExecContext(repo.context(), "DELETE FROM "+table+" WHERE "+keyCol+" = ANY($1)", pq.Array(keys))
+	return err
+}
+
+// execBatched appends the rows as VALUES tuples to prefix, splitting them into
+// as many statements as the bind parameter limit requires.
+func execBatched(repo *SQLRepository, prefix string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	perRow := len(rows[0])
+	batch := maxParams / perRow
+
+	for start := 0; start < len(rows); start += batch {
+		end := start + batch
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		var tuples []string
+		var args []interface{}
+		for _, row := range rows[start:end] {
+			placeholders := make([]string, len(row))
+			for i, value := range row {
+				args = append(args, value)
+				placeholders[i] = "$" + strconv.Itoa(len(args))
+			}
+			tuples = append(tuples, "("+strings.Join(placeholders, ", ")+")")
+		}
+
+		if _, err := repo.db.ExecContext(repo.context(), prefix+strings.Join(tuples, ", "), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
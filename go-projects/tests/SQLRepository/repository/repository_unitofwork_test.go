@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"m/tests/SQLRepository/entities"
+	"reflect"
+	"testing"
+)
+
+func TestUnitOfWorkTableOrder(t *testing.T) {
+	uow := NewUnitOfWork(nil, ProjectSchema)
+	uow.RegisterLinks(NewBaseLinks("TASK_RESOURCE", "TASK_ID", "RESOURCE_ID").NewLinks(1, []int{1}))
+	uow.RegisterNew(&entities.Task{ID: 1})
+	uow.RegisterNew(&entities.Resource{ID: 1})
+	uow.RegisterNew(&entities.Project{ID: 1})
+
+	order, err := uow.tableOrder()
+	if err != nil {
+		t.Fatalf("tableOrder failed: %v", err)
+	}
+	want := []string{"projects", "resources", "tasks", "task_resource"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("tableOrder() = %v, want %v", order, want)
+	}
+}
+
+func TestUnitOfWorkTableOrderDetectsCycles(t *testing.T) {
+	uow := NewUnitOfWork(nil, []ForeignKey{
+		{Table: "a", Column: "b_id", RefTable: "b"},
+		{Table: "b", Column: "a_id", RefTable: "a"},
+	})
+	if _, err := uow.tableOrder(); err == nil {
+		t.Error("Expected a cycle between a and b to be reported")
+	}
+}
+
+func TestUnitOfWorkRegistrations(t *testing.T) {
+	uow := NewUnitOfWork(nil, ProjectSchema)
+	created := &entities.Project{ID: 1}
+	loaded := &entities.Project{ID: 2}
+
+	uow.RegisterNew(created)
+	uow.RegisterDirty(created)
+	uow.RegisterDirty(loaded)
+	uow.RegisterDirty(loaded)
+	if len(uow.created) != 1 || len(uow.dirty) != 1 {
+		t.Fatalf("Expected 1 new and 1 dirty entity, got %d and %d", len(uow.created), len(uow.dirty))
+	}
+
+	uow.RegisterDeleted(created)
+	uow.RegisterDeleted(loaded)
+	if len(uow.created) != 0 || len(uow.dirty) != 0 || len(uow.deleted) != 1 {
+		t.Errorf("Expected only the loaded project to be deleted, got %d new, %d dirty, %d deleted",
+			len(uow.created), len(uow.dirty), len(uow.deleted))
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"m/tests/StoredProcedure/entities"
+	"m/utils/orderby"
+	"m/utils/trace"
+)
+
+// InsertResource inserts a single resource into the RESOURCES table. Resources
+// are not part of a project graph, so they are written by a plain INSERT.
+func InsertResource(db *sql.DB, resource entities.Resource) (int, error) {
+	ctx, span := trace.Start(context.Background(), "StoredProcedure.InsertResource", "resource.id", resource.ID)
+	defer span.End()
+
+	query := `
+		INSERT INTO RESOURCES (ID, TYPE, NAME, DAILY_COST, STATUS, SUPPLIER, QUANTITY, ACQUISITION_DATE)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ID
+	`
+	var resourceID int
+	err := db.QueryRowContext(ctx, query, resource.ID, resource.Type, resource.Name, resource.DailyCost, resource.Status, resource.Supplier, resource.Quantity, resource.AcquisitionDate).Scan(&resourceID)
+	if err != nil {
+		return 0, err
+	}
+
+	return resourceID, nil
+}
+
+// InsertProject sends the project, its tasks and their links to INSERT_PROJECT
+// as one JSONB document.
+func InsertProject(db *sql.DB, project entities.Project) (int, error) {
+	ctx, span := trace.Start(context.Background(), "StoredProcedure.InsertProject", "project.id", project.ID)
+	defer span.End()
+
+	document, err := json.Marshal(project)
+	if err != nil {
+		return 0, err
+	}
+
+	var projectID int
+	err = db.QueryRowContext(ctx, `SELECT INSERT_PROJECT($1::JSONB)`, string(document)).Scan(&projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	return projectID, nil
+}
+
+// Reads a project by ID, including its tasks and resources sorted by key or by the given order.
+// READ_PROJECT builds the project document, so a single row comes back.
+func ReadProject(db *sql.DB, projectID int, order ...orderby.OrderBy) (*entities.Project, error) {
+	ctx, span := trace.Start(context.Background(), "StoredProcedure.ReadProject", "project.id", projectID)
+	defer span.End()
+
+	resolved := orderby.Resolve(order)
+	taskOrder, err := resolved.TaskClause("t")
+	if err != nil {
+		return nil, err
+	}
+	resourceOrder, err := resolved.ResourceClause("r")
+	if err != nil {
+		return nil, err
+	}
+
+	var document []byte
+	err = db.QueryRowContext(ctx, `SELECT READ_PROJECT($1, $2, $3)`, projectID, taskOrder, resourceOrder).Scan(&document)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	var project entities.Project
+	if err := json.Unmarshal(document, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// UpdateProject sends the project and its tasks to UPDATE_PROJECT as one JSONB
+// document.
+func UpdateProject(db *sql.DB, project *entities.Project) error {
+	ctx, span := trace.Start(context.Background(), "StoredProcedure.UpdateProject", "project.id", project.ID)
+	defer span.End()
+
+	document, err := json.Marshal(project)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `SELECT UPDATE_PROJECT($1::JSONB)`, string(document))
+	return err
+}
+
+// Deletes a project by ID.
+func DeleteProject(db *sql.DB, projectID int) error {
+	ctx, span := trace.Start(context.Background(), "StoredProcedure.DeleteProject", "project.id", projectID)
+	defer span.End()
+
+	_, err := db.ExecContext(ctx, `SELECT DELETE_PROJECT($1)`, projectID)
+	return err
+}
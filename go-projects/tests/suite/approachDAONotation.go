@@ -0,0 +1,28 @@
+package suite
+
+import (
+	"database/sql"
+	base "m/tests/Base"
+	"m/tests/DAONotation/entities"
+	"m/tests/DAONotation/repository"
+)
+
+func init() {
+	base.RegisterApproach("DAONotation", func() base.Approach {
+		return base.Adapt(base.Funcs[*sql.DB, entities.Resource, entities.Project]{
+			Open:  base.SetupDB,
+			Close: (*sql.DB).Close,
+			SQL:   func(db *sql.DB) (*sql.DB, error) { return db, nil },
+
+			InsertResource: repository.InsertResource,
+			InsertProject:  repository.InsertProject,
+			ReadProject:    repository.ReadProject,
+			UpdateProject:  repository.UpdateProject,
+			DeleteProject:  repository.DeleteProject,
+
+			// ReadProject is an N+1 read: one query for the project, one
+			// for its tasks and one per task for their resources.
+			ReadBudget: func(project base.BaseProject) int64 { return int64(2 + len(project.Tasks)) },
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package suite
+
+import (
+	base "m/tests/Base"
+	"m/tests/GORM/entities"
+	"m/tests/GORM/repository"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	base.RegisterApproach("GORM", func() base.Approach {
+		return base.Adapt(base.Funcs[*gorm.DB, entities.Resource, entities.Project]{
+			Open: base.SetupGorm,
+			Close: func(db *gorm.DB) error {
+				sqlDB, err := db.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.Close()
+			},
+			SQL: (*gorm.DB).DB,
+
+			InsertResource: repository.InsertResource,
+			InsertProject:  repository.InsertProject,
+			ReadProject:    repository.ReadProject,
+			UpdateProject:  repository.UpdateProject,
+			DeleteProject:  repository.DeleteProject,
+
+			// ReadProject runs one query for the project and one per preload,
+			// for the tasks, their task_resource links and the resources.
+			ReadBudget: func(base.BaseProject) int64 { return 4 },
+		})
+	})
+}
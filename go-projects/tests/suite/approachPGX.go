@@ -0,0 +1,42 @@
+package suite
+
+import (
+	"database/sql"
+	base "m/tests/Base"
+	"m/tests/PGX/entities"
+	"m/tests/PGX/repository"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+func init() {
+	base.RegisterApproach("PGX", func() base.Approach {
+		return base.Adapt(base.Funcs[*pgxpool.Pool, entities.Resource, entities.Project]{
+			Open: base.SetupPgx,
+			Close: func(pool *pgxpool.Pool) error {
+				pool.Close()
+				return nil
+			},
+			SQL: func(pool *pgxpool.Pool) (*sql.DB, error) {
+				return stdlib.OpenDB(*pool.Config().ConnConfig), nil
+			},
+
+			InsertResource: repository.InsertResource,
+			InsertProject:  repository.InsertProject,
+			ReadProject:    repository.ReadProject,
+			UpdateProject:  repository.UpdateProject,
+			DeleteProject:  repository.DeleteProject,
+
+			// ReadProject is a single joined query.
+			ReadBudget: func(base.BaseProject) int64 { return 1 },
+			// pgxpool counts the acquires that found no idle connection, but
+			// times every acquire.
+			PoolWait: func(pool *pgxpool.Pool) (int64, time.Duration) {
+				stat := pool.Stat()
+				return stat.EmptyAcquireCount(), stat.AcquireDuration()
+			},
+		})
+	})
+}
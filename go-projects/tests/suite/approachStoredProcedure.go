@@ -0,0 +1,27 @@
+package suite
+
+import (
+	"database/sql"
+	base "m/tests/Base"
+	"m/tests/StoredProcedure/entities"
+	"m/tests/StoredProcedure/repository"
+)
+
+func init() {
+	base.RegisterApproach("StoredProcedure", func() base.Approach {
+		return base.Adapt(base.Funcs[*sql.DB, entities.Resource, entities.Project]{
+			Open:  base.SetupDB,
+			Close: (*sql.DB).Close,
+			SQL:   func(db *sql.DB) (*sql.DB, error) { return db, nil },
+
+			InsertResource: repository.InsertResource,
+			InsertProject:  repository.InsertProject,
+			ReadProject:    repository.ReadProject,
+			UpdateProject:  repository.UpdateProject,
+			DeleteProject:  repository.DeleteProject,
+
+			// ReadProject is a single call of READ_PROJECT.
+			ReadBudget: func(base.BaseProject) int64 { return 1 },
+		})
+	})
+}
@@ -0,0 +1,5 @@
+// Package suite runs the same benchmarks and conformance test over every
+// approach registered with base.RegisterApproach, as one sub-benchmark or
+// subtest per approach: BenchmarkReadProject/GORM. The approach*.go files
+// register an adapter for the repository of each approach.
+package suite
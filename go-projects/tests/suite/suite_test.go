@@ -0,0 +1,269 @@
+package suite
+
+import (
+	"fmt"
+	base "m/tests/Base"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// TestConformance stores, reads back, updates and deletes the input data
+// through each approach, checking that what is read matches what was stored
+// and that ReadProject keeps to the round trips the approach pins. It replaces
+// the contents of the test database, and is skipped without it.
+func TestConformance(t *testing.T) {
+	base.SkipWithoutDB(t)
+	base.TestApproaches(t, func(t *testing.T, approach base.Approach, data base.TestInput) {
+		base.Prepare(t, approach, data, base.WithProjects)
+
+		for i, project := range data.Projects {
+			readProject, err := approach.ReadProject(i)
+			if err != nil {
+				t.Fatalf("Failed to read project: %v", err)
+			}
+			if err := base.CompareObjects(approach.Project(i), readProject); err != nil {
+				t.Errorf("Project %d does not match: %v", project.ID, err)
+			}
+
+			if budgeter, ok := approach.(base.QueryBudgeter); ok {
+				if budget, ok := budgeter.ReadProjectBudget(project); ok {
+					name := fmt.Sprintf("ReadProject(%d)", project.ID)
+					base.RequireQueryBudget(t, name, budget, func() error {
+						_, err := approach.ReadProject(i)
+						return err
+					})
+				}
+			}
+		}
+
+		for i := range data.Projects {
+			if err := approach.UpdateProject(i); err != nil {
+				t.Fatalf("Failed to update project: %v", err)
+			}
+		}
+		for i := range data.Projects {
+			if err := approach.DeleteProject(i); err != nil {
+				t.Fatalf("Failed to delete project: %v", err)
+			}
+		}
+	})
+}
+
+// BenchmarkInsertResources inserts the resources into an empty database. The
+// tables are emptied again, outside the timer, before each iteration, so the
+// same IDs can be inserted each time instead of new unique ones.
+func BenchmarkInsertResources(b *testing.B) {
+	base.RunApproaches(b, func(b *testing.B, approach base.Approach, data base.TestInput) {
+		base.Prepare(b, approach, data, base.Empty)
+
+		queries := base.CountQueries(b)
+		b.ResetTimer() // Start benchmark timer here to exclude setup time.
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			queries.Stop()
+			if err := base.Truncate(approach.DB()); err != nil {
+				b.Fatalf("Error cleaning database: %s", err)
+			}
+			queries.Start()
+			b.StartTimer()
+
+			for r := range data.Resources {
+				if err := approach.InsertResource(r); err != nil {
+					b.Fatalf("Failed to insert resource: %v", err)
+				}
+			}
+		}
+		queries.Report()
+	})
+}
+
+// BenchmarkInsertProject inserts the projects next to the resources. The
+// projects are emptied again, outside the timer, before each iteration, so the
+// same IDs can be inserted each time instead of new unique ones.
+func BenchmarkInsertProject(b *testing.B) {
+	base.RunApproaches(b, func(b *testing.B, approach base.Approach, data base.TestInput) {
+		base.Prepare(b, approach, data, base.WithResources)
+
+		queries := base.CountQueries(b)
+		b.ResetTimer() // Start benchmark timer here to exclude setup time.
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			queries.Stop()
+			if err := base.TruncateProjects(approach.DB()); err != nil {
+				b.Fatalf("Error cleaning projects: %s", err)
+			}
+			queries.Start()
+			b.StartTimer()
+
+			for p := range data.Projects {
+				if err := approach.InsertProject(p); err != nil {
+					b.Fatalf("Failed to insert project: %v", err)
+				}
+			}
+		}
+		queries.Report()
+	})
+}
+
+// BenchmarkReadProject measures the performance of the ReadProject method.
+func BenchmarkReadProject(b *testing.B) {
+	base.RunApproaches(b, func(b *testing.B, approach base.Approach, data base.TestInput) {
+		base.Prepare(b, approach, data, base.WithProjects)
+		checkReads(b, approach, data)
+
+		queries := base.CountQueries(b)
+		b.ResetTimer() // Start benchmark timer here to exclude setup time.
+
+		for i := 0; i < b.N; i++ {
+			for p := range data.Projects {
+				if _, err := approach.ReadProject(p); err != nil {
+					b.Fatalf("Failed to read project: %v", err)
+				}
+			}
+		}
+		queries.Report()
+	})
+}
+
+// checkReads compares every project read back with its input once, before a
+// read benchmark starts its timer, so that the comparison is not measured.
+func checkReads(b *testing.B, approach base.Approach, data base.TestInput) {
+	b.Helper()
+	for p := range data.Projects {
+		readProject, err := approach.ReadProject(p)
+		if err != nil {
+			b.Fatalf("Failed to read project: %v", err)
+		}
+		if err := base.CompareObjects(approach.Project(p), readProject); err != nil {
+			b.Errorf("Objects do not match:\n%v", err)
+		}
+	}
+}
+
+// Benchmark for updating a project. The update is the same in every
+// iteration, so the stored projects need no reset.
+func BenchmarkUpdateProject(b *testing.B) {
+	base.RunApproaches(b, func(b *testing.B, approach base.Approach, data base.TestInput) {
+		base.Prepare(b, approach, data, base.WithProjects)
+
+		queries := base.CountQueries(b)
+		b.ResetTimer() // Start benchmark timer here to exclude setup time.
+
+		for i := 0; i < b.N; i++ {
+			for p := range data.Projects {
+				if err := approach.UpdateProject(p); err != nil {
+					b.Fatalf("Failed to update project: %v", err)
+				}
+			}
+		}
+		queries.Report()
+	})
+}
+
+// Benchmark for deleting a project. The projects are stored again, outside
+// the timer, before each iteration.
+func BenchmarkDeleteProject(b *testing.B) {
+	base.RunApproaches(b, func(b *testing.B, approach base.Approach, data base.TestInput) {
+		base.Prepare(b, approach, data, base.WithResources)
+
+		queries := base.CountQueries(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			queries.Stop()
+			base.StoreProjects(b, approach, data)
+			queries.Start()
+			b.StartTimer()
+
+			for p := range data.Projects {
+				if err := approach.DeleteProject(p); err != nil {
+					b.Fatalf("Failed to delete project: %v", err)
+				}
+			}
+		}
+		queries.Report()
+	})
+}
+
+// The parallel benchmarks run one operation on one project per iteration from
+// GOMAXPROCS goroutines, or as many as -cpu sets, and prepare their fixture
+// first. Their ns/op is wall time divided by operations, so compare them
+// through ops/s and the pool waits across -cpu and POOL_SIZE values.
+
+// BenchmarkParallelReadProject reads the projects concurrently, several
+// goroutines reading the same project at times.
+func BenchmarkParallelReadProject(b *testing.B) {
+	base.RunApproaches(b, func(b *testing.B, approach base.Approach, data base.TestInput) {
+		base.Prepare(b, approach, data, base.WithProjects)
+		checkReads(b, approach, data)
+		projects := base.NewCycle(len(data.Projects))
+
+		meter := base.MeasureParallel(b, approach)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := approach.ReadProject(projects.Next()); err != nil {
+					b.Errorf("Failed to read project: %v", err)
+					return
+				}
+			}
+		})
+		meter.Report()
+	})
+}
+
+// BenchmarkParallelUpdateProject updates the projects concurrently, each
+// project by one goroutine at a time so that they do not wait on row locks.
+func BenchmarkParallelUpdateProject(b *testing.B) {
+	base.RunApproaches(b, func(b *testing.B, approach base.Approach, data base.TestInput) {
+		base.Prepare(b, approach, data, base.WithProjects)
+		projects := base.NewClaims(len(data.Projects))
+
+		meter := base.MeasureParallel(b, approach)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				p := projects.Claim()
+				err := approach.UpdateProject(p)
+				projects.Release(p)
+				if err != nil {
+					b.Errorf("Failed to update project: %v", err)
+					return
+				}
+			}
+		})
+		meter.Report()
+	})
+}
+
+// BenchmarkParallelInsertDeleteProject inserts and deletes the projects
+// concurrently. A project can only be inserted again once deleted, so each
+// iteration does both; compare it with the sum of InsertProject and
+// DeleteProject.
+func BenchmarkParallelInsertDeleteProject(b *testing.B) {
+	base.RunApproaches(b, func(b *testing.B, approach base.Approach, data base.TestInput) {
+		base.Prepare(b, approach, data, base.WithResources)
+		projects := base.NewClaims(len(data.Projects))
+
+		meter := base.MeasureParallel(b, approach)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				p := projects.Claim()
+				err := approach.InsertProject(p)
+				if err == nil {
+					err = approach.DeleteProject(p)
+				}
+				projects.Release(p)
+				if err != nil {
+					b.Errorf("Failed to insert and delete project: %v", err)
+					return
+				}
+			}
+		})
+		meter.Report()
+	})
+}
@@ -0,0 +1,233 @@
+// Package assembler rebuilds entity trees from the flat rows of an outer join.
+//
+// Each Node owns a contiguous group of columns in the select list. Columns are
+// laid out depth-first: the root columns first, then each child's columns
+// followed by its own children, in the order they were joined.
+package assembler
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Node maps one level of the joined rows onto an entity type.
+type Node struct {
+	width    int
+	key      int
+	table    string
+	typ      reflect.Type
+	create   func() interface{}
+	fields   func(entity interface{}) []interface{}
+	children []edge
+}
+
+type edge struct {
+	node   *Node
+	reset  func(parent interface{})
+	attach func(parent, child interface{})
+}
+
+// Identity shares entity instances across rows and queries. Resolve returns
+// the instance already known for table and key, or stores the one built by load.
+type Identity interface {
+	Resolve(table string, key interface{}, load func() (interface{}, error)) (interface{}, error)
+}
+
+// New describes an entity whose scan targets are returned by fields, in
+// select-list order. key is the position of its key column within them; rows
+// where the key is NULL are treated as a missing outer-join match.
+func New[T any](fields func(*T) []interface{}, key int) *Node {
+	var model T
+	width := len(fields(&model))
+	if key < 0 || key >= width {
+		panic(fmt.Sprintf("assembler: key index %d out of range for %d columns", key, width))
+	}
+	return &Node{
+		width:  width,
+		key:    key,
+		typ:    reflect.TypeOf(&model),
+		create: func() interface{} { return new(T) },
+		fields: func(entity interface{}) []interface{} { return fields(entity.(*T)) },
+	}
+}
+
+// Table names the table a node is loaded from, which makes its entities
+// shareable through an Identity. The Identity holds one instance per row, so
+// every node of a table must map it to the same type.
+func (n *Node) Table(name string) *Node {
+	n.table = name
+	return n
+}
+
+// Join declares child as the collection of parent returned by collection. The
+// collection is rebuilt from the rows, each distinct child appended after its
+// own collections are complete. Children are copied into the collection, so
+// the collection does not hold the instances shared through an Identity; use
+// JoinRefs for that.
+func Join[P, C any](parent, child *Node, collection func(*P) *[]C) {
+	parent.children = append(parent.children, edge{
+		node:  child,
+		reset: func(p interface{}) { *collection(p.(*P)) = nil },
+		attach: func(p, c interface{}) {
+			items := collection(p.(*P))
+			*items = append(*items, *c.(*C))
+		},
+	})
+}
+
+// JoinRefs is Join for a collection of pointers: each child is attached as
+// the instance assembled, or resolved through an Identity, for its row.
+func JoinRefs[P, C any](parent, child *Node, collection func(*P) *[]*C) {
+	parent.children = append(parent.children, edge{
+		node:  child,
+		reset: func(p interface{}) { *collection(p.(*P)) = nil },
+		attach: func(p, c interface{}) {
+			items := collection(p.(*P))
+			*items = append(*items, c.(*C))
+		},
+	})
+}
+
+// instance is one distinct entity found in the rows, with its children kept
+// in the order they were first seen.
+type instance struct {
+	entity   interface{}
+	children []*collection
+}
+
+type collection struct {
+	order []*instance
+	byKey map[interface{}]*instance
+}
+
+func newCollection() *collection {
+	return &collection{byKey: make(map[interface{}]*instance)}
+}
+
+// Assemble scans every row, deduplicating entities by key within their parent,
+// and returns the distinct roots in the order they were first seen. rows is
+// closed before returning.
+func Assemble[T any](rows *sql.Rows, root *Node) ([]*T, error) {
+	return AssembleWith[T](rows, root, nil)
+}
+
+// AssembleWith is Assemble resolving the entities of nodes with a Table
+// through identity, so a row already known there is not loaded again.
+func AssembleWith[T any](rows *sql.Rows, root *Node, identity Identity) ([]*T, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if total := root.totalWidth(); len(columns) != total {
+		return nil, fmt.Errorf("assembler: query returns %d columns, mapping expects %d", len(columns), total)
+	}
+
+	values := make([]interface{}, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+
+	roots := newCollection()
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return nil, err
+		}
+		if _, err := root.visit(roots, values, 0, identity); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*T, 0, len(roots.order))
+	for _, inst := range roots.order {
+		root.finish(inst)
+		result = append(result, inst.entity.(*T))
+	}
+	return result, nil
+}
+
+func (n *Node) totalWidth() int {
+	total := n.width
+	for _, e := range n.children {
+		total += e.node.totalWidth()
+	}
+	return total
+}
+
+// visit handles the columns of this node and its descendants, starting at
+// offset, and returns the offset just past them.
+func (n *Node) visit(set *collection, values []interface{}, offset int, identity Identity) (int, error) {
+	end := offset + n.totalWidth()
+	key := values[offset+n.key]
+	if key == nil {
+		return end, nil
+	}
+	if b, ok := key.([]byte); ok {
+		key = string(b)
+	}
+
+	inst, exists := set.byKey[key]
+	if !exists {
+		entity, err := n.resolve(key, values[offset:offset+n.width], identity)
+		if err != nil {
+			return end, err
+		}
+		inst = &instance{entity: entity, children: make([]*collection, len(n.children))}
+		for i := range inst.children {
+			inst.children[i] = newCollection()
+		}
+		set.byKey[key] = inst
+		set.order = append(set.order, inst)
+	}
+
+	next := offset + n.width
+	for i, e := range n.children {
+		var err error
+		next, err = e.node.visit(inst.children[i], values, next, identity)
+		if err != nil {
+			return end, err
+		}
+	}
+	return end, nil
+}
+
+func (n *Node) resolve(key interface{}, values []interface{}, identity Identity) (interface{}, error) {
+	load := func() (interface{}, error) {
+		entity := n.create()
+		return entity, n.load(entity, values)
+	}
+	if identity == nil || n.table == "" {
+		return load()
+	}
+	entity, err := identity.Resolve(n.table, key, load)
+	if err == nil && reflect.TypeOf(entity) != n.typ {
+		return nil, fmt.Errorf("assembler: %s row %v is held as %T, not %v", n.table, key, entity, n.typ)
+	}
+	return entity, err
+}
+
+func (n *Node) load(entity interface{}, values []interface{}) error {
+	for i, field := range n.fields(entity) {
+		if err := assign(field, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish attaches children bottom-up, so value collections receive complete copies.
+func (n *Node) finish(inst *instance) {
+	for i, e := range n.children {
+		e.reset(inst.entity)
+		for _, child := range inst.children[i].order {
+			e.node.finish(child)
+			e.attach(inst.entity, child.entity)
+		}
+	}
+}
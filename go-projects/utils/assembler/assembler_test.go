@@ -0,0 +1,227 @@
+package assembler
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// rowsDriver serves a fixed result set for any query.
+type rowsDriver struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (d *rowsDriver) Open(string) (driver.Conn, error)    { return d, nil }
+func (d *rowsDriver) Prepare(string) (driver.Stmt, error) { return d, nil }
+func (d *rowsDriver) Close() error                        { return nil }
+func (d *rowsDriver) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+func (d *rowsDriver) NumInput() int                       { return -1 }
+func (d *rowsDriver) Exec([]driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+func (d *rowsDriver) Query([]driver.Value) (driver.Rows, error) {
+	return &fixedRows{columns: d.columns, rows: d.rows}, nil
+}
+
+type fixedRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fixedRows) Columns() []string { return r.columns }
+func (r *fixedRows) Close() error      { return nil }
+func (r *fixedRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type project struct {
+	ID        int
+	StartDate time.Time
+	Budget    *float64
+	Tasks     []task
+}
+
+type task struct {
+	ID        int
+	Name      string
+	Resources []resource
+}
+
+type resource struct {
+	ID       int
+	Quantity *int
+}
+
+func projectTree() *Node {
+	p := New(func(p *project) []interface{} { return []interface{}{&p.ID, &p.StartDate, &p.Budget} }, 0)
+	t := New(func(t *task) []interface{} { return []interface{}{&t.ID, &t.Name} }, 0)
+	r := New(func(r *resource) []interface{} { return []interface{}{&r.ID, &r.Quantity} }, 0)
+	Join(p, t, func(p *project) *[]task { return &p.Tasks })
+	Join(t, r, func(t *task) *[]resource { return &t.Resources })
+	return p
+}
+
+var queries int
+
+func query(t *testing.T, rows [][]driver.Value) *sql.Rows {
+	queries++
+	name := fmt.Sprintf("%s/%d", t.Name(), queries)
+	sql.Register(name, &rowsDriver{
+		columns: []string{"p.id", "p.start_date", "p.budget", "t.id", "t.name", "r.id", "r.quantity"},
+		rows:    rows,
+	})
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	result, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestAssembleTree(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	rows := query(t, [][]driver.Value{
+		{int64(1), start, []byte("10.50"), int64(7), "first", int64(3), int64(2)},
+		{int64(1), start, []byte("10.50"), int64(7), "first", int64(4), nil},
+		{int64(1), start, []byte("10.50"), int64(5), "second", int64(3), int64(2)},
+		{int64(1), start, []byte("10.50"), int64(6), "empty", nil, nil},
+	})
+
+	projects, err := Assemble[project](rows, projectTree())
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	if len(projects) != 1 {
+		t.Fatalf("Expected one project, got %d", len(projects))
+	}
+
+	p := projects[0]
+	if !p.StartDate.Equal(start) || p.Budget == nil || *p.Budget != 10.5 {
+		t.Errorf("Project columns not assigned: %+v", p)
+	}
+	if len(p.Tasks) != 3 || p.Tasks[0].ID != 7 || p.Tasks[1].ID != 5 || p.Tasks[2].ID != 6 {
+		t.Fatalf("Expected tasks 7, 5, 6 in row order, got %+v", p.Tasks)
+	}
+	if got := p.Tasks[0].Resources; len(got) != 2 || got[0].ID != 3 || got[1].Quantity != nil {
+		t.Errorf("Unexpected resources for task 7: %+v", got)
+	}
+	if got := p.Tasks[1].Resources; len(got) != 1 || got[0].ID != 3 || *got[0].Quantity != 2 {
+		t.Errorf("Shared resource not attached to task 5: %+v", got)
+	}
+	if got := p.Tasks[2].Resources; got != nil {
+		t.Errorf("Expected NULL outer-join row to yield no resources, got %+v", got)
+	}
+}
+
+// mapIdentity is a minimal Identity keyed by table and key.
+type mapIdentity map[string]interface{}
+
+func (m mapIdentity) Resolve(table string, key interface{}, load func() (interface{}, error)) (interface{}, error) {
+	id := table + "/" + fmt.Sprint(key)
+	if entity, exists := m[id]; exists {
+		return entity, nil
+	}
+	entity, err := load()
+	m[id] = entity
+	return entity, err
+}
+
+func TestAssembleWithIdentity(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	rows := [][]driver.Value{
+		{int64(1), start, nil, int64(7), "first", int64(3), int64(2)},
+		{int64(1), start, nil, int64(5), "second", int64(3), int64(2)},
+	}
+	tree := projectTree()
+	tree.Table("projects")
+	identity := mapIdentity{}
+
+	first, err := AssembleWith[project](query(t, rows), tree, identity)
+	if err != nil {
+		t.Fatalf("AssembleWith failed: %v", err)
+	}
+	second, err := AssembleWith[project](query(t, rows), tree, identity)
+	if err != nil {
+		t.Fatalf("AssembleWith failed: %v", err)
+	}
+
+	if first[0] != second[0] {
+		t.Error("Expected the same project instance from both reads")
+	}
+	if len(second[0].Tasks) != 2 {
+		t.Errorf("Expected collections to be rebuilt, not appended to, got %d tasks", len(second[0].Tasks))
+	}
+	if len(identity) != 1 {
+		t.Errorf("Expected only the named table to be shared, got %d entries", len(identity))
+	}
+}
+
+type refProject struct {
+	ID    int
+	Tasks []*refTask
+}
+
+type refTask struct {
+	ID        int
+	Resources []*resource
+}
+
+func TestJoinRefsSharesInstances(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	rows := [][]driver.Value{
+		{int64(1), start, nil, int64(7), "first", int64(3), int64(2)},
+		{int64(1), start, nil, int64(5), "second", int64(3), int64(2)},
+	}
+	p := New(func(p *refProject) []interface{} { return []interface{}{&p.ID, new(time.Time), new(*float64)} }, 0).Table("projects")
+	tk := New(func(t *refTask) []interface{} { return []interface{}{&t.ID, new(string)} }, 0).Table("tasks")
+	r := New(func(r *resource) []interface{} { return []interface{}{&r.ID, &r.Quantity} }, 0).Table("resources")
+	JoinRefs(p, tk, func(p *refProject) *[]*refTask { return &p.Tasks })
+	JoinRefs(tk, r, func(t *refTask) *[]*resource { return &t.Resources })
+	identity := mapIdentity{}
+
+	first, err := AssembleWith[refProject](query(t, rows), p, identity)
+	if err != nil {
+		t.Fatalf("AssembleWith failed: %v", err)
+	}
+	second, err := AssembleWith[refProject](query(t, rows), p, identity)
+	if err != nil {
+		t.Fatalf("AssembleWith failed: %v", err)
+	}
+
+	shared := identity["resources/3"]
+	for _, read := range [][]*refProject{first, second} {
+		tasks := read[0].Tasks
+		if len(tasks) != 2 || tasks[0] != identity["tasks/7"] || tasks[1] != identity["tasks/5"] {
+			t.Fatalf("Expected the tasks of the identity, got %+v", tasks)
+		}
+		for _, task := range tasks {
+			if len(task.Resources) != 1 || task.Resources[0] != shared {
+				t.Errorf("Expected task %d to hold the shared resource, got %+v", task.ID, task.Resources)
+			}
+		}
+	}
+}
+
+func TestAssembleNoRows(t *testing.T) {
+	projects, err := Assemble[project](query(t, nil), projectTree())
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	if len(projects) != 0 {
+		t.Errorf("Expected no projects, got %d", len(projects))
+	}
+}
@@ -0,0 +1,134 @@
+package assembler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var errNilTarget = errors.New("assembler: scan target must be a non-nil pointer")
+
+// assign stores a driver value into a scan target. NULL resets the target to
+// its zero value, so nullable columns should map to pointer fields.
+func assign(dest, src interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return errNilTarget
+	}
+	return assignValue(target.Elem(), src)
+}
+
+func assignValue(dest reflect.Value, src interface{}) error {
+	if src == nil {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+
+	if dest.Kind() == reflect.Ptr {
+		value := reflect.New(dest.Type().Elem())
+		if err := assignValue(value.Elem(), src); err != nil {
+			return err
+		}
+		dest.Set(value)
+		return nil
+	}
+
+	if b, ok := src.([]byte); ok {
+		src = string(b)
+	}
+
+	switch dest.Kind() {
+	case reflect.String:
+		switch v := src.(type) {
+		case string:
+			dest.SetString(v)
+			return nil
+		case time.Time:
+			dest.SetString(v.Format(time.RFC3339Nano))
+			return nil
+		}
+		dest.SetString(fmt.Sprint(src))
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v := src.(type) {
+		case int64:
+			dest.SetInt(v)
+			return nil
+		case string:
+			n, err := strconv.ParseInt(v, 10, dest.Type().Bits())
+			if err != nil {
+				return err
+			}
+			dest.SetInt(n)
+			return nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := src.(type) {
+		case int64:
+			dest.SetUint(uint64(v))
+			return nil
+		case string:
+			n, err := strconv.ParseUint(v, 10, dest.Type().Bits())
+			if err != nil {
+				return err
+			}
+			dest.SetUint(n)
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch v := src.(type) {
+		case float64:
+			dest.SetFloat(v)
+			return nil
+		case int64:
+			dest.SetFloat(float64(v))
+			return nil
+		case string:
+			n, err := strconv.ParseFloat(v, dest.Type().Bits())
+			if err != nil {
+				return err
+			}
+			dest.SetFloat(n)
+			return nil
+		}
+
+	case reflect.Bool:
+		switch v := src.(type) {
+		case bool:
+			dest.SetBool(v)
+			return nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			dest.SetBool(b)
+			return nil
+		}
+
+	case reflect.Struct:
+		if v, ok := src.(time.Time); ok && dest.Type() == timeType {
+			dest.Set(reflect.ValueOf(v))
+			return nil
+		}
+	}
+
+	value := reflect.ValueOf(src)
+	if value.Type().ConvertibleTo(dest.Type()) {
+		dest.Set(value.Convert(dest.Type()))
+		return nil
+	}
+	return fmt.Errorf("assembler: cannot assign %T to %s", src, dest.Type())
+}
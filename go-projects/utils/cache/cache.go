@@ -0,0 +1,234 @@
+// Package cache is a size-bounded LRU cache with TTL for repository reads.
+// Every entry records the rows it was read from, so a write to any of those
+// rows evicts it.
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key identifies a database row.
+type Key struct {
+	Table string
+	ID    int
+}
+
+func NewKey(table string, id int) Key {
+	return Key{Table: strings.ToLower(table), ID: id}
+}
+
+// Stats are the cache counters since it was created.
+type Stats struct {
+	Hits          uint64
+	Misses        uint64
+	Evictions     uint64 // entries dropped for size or TTL
+	Invalidations uint64 // entries dropped because a row they depend on changed
+	Size          int
+}
+
+// HitRatio returns the share of lookups served from the cache.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type entry struct {
+	name    string
+	value   interface{}
+	expires time.Time
+	rows    []Key
+}
+
+// Cache holds at most capacity entries, each for at most ttl. Cached values
+// are shared between callers and must be treated as read-only.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	lru      *list.List
+	entries  map[string]*list.Element
+	byRow    map[Key]map[string]struct{}
+	stats    Stats
+	// generation counts the invalidations, so that ReadThrough can tell
+	// whether one ran while it was loading.
+	generation uint64
+}
+
+// New creates a cache. A zero ttl keeps entries until they are evicted or invalidated.
+func New(capacity int, ttl time.Duration) *Cache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Cache{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		byRow:    make(map[Key]map[string]struct{}),
+	}
+}
+
+// Get returns the value cached under name.
+func (c *Cache) Get(name string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[name]
+	if !exists {
+		c.stats.Misses++
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if c.ttl > 0 && c.now().After(e.expires) {
+		c.remove(element)
+		c.stats.Evictions++
+		c.stats.Misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	c.stats.Hits++
+	return e.value, true
+}
+
+// Set caches value under name, recording the rows it was read from.
+func (c *Cache) Set(name string, value interface{}, rows ...Key) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(name, value, rows)
+}
+
+// setSince caches value like Set unless the cache was invalidated after
+// generation, in which case value may predate the write and is dropped.
+func (c *Cache) setSince(generation uint64, name string, value interface{}, rows []Key) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	c.set(name, value, rows)
+}
+
+func (c *Cache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+func (c *Cache) set(name string, value interface{}, rows []Key) {
+	if element, exists := c.entries[name]; exists {
+		c.remove(element)
+	}
+
+	e := &entry{name: name, value: value, expires: c.now().Add(c.ttl), rows: rows}
+	c.entries[name] = c.lru.PushFront(e)
+	for _, row := range rows {
+		names, exists := c.byRow[row]
+		if !exists {
+			names = make(map[string]struct{})
+			c.byRow[row] = names
+		}
+		names[name] = struct{}{}
+	}
+
+	for c.lru.Len() > c.capacity {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// Invalidate evicts every entry read from any of rows and returns how many were dropped.
+func (c *Cache) Invalidate(rows ...Key) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	dropped := 0
+	for _, row := range rows {
+		for name := range c.byRow[row] {
+			if element, exists := c.entries[name]; exists {
+				c.remove(element)
+				dropped++
+			}
+		}
+	}
+	c.stats.Invalidations += uint64(dropped)
+	return dropped
+}
+
+// InvalidateTable evicts every entry read from any row of table.
+func (c *Cache) InvalidateTable(table string) int {
+	c.mu.Lock()
+	var rows []Key
+	for row := range c.byRow {
+		if row.Table == strings.ToLower(table) {
+			rows = append(rows, row)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.Invalidate(rows...)
+}
+
+// Purge evicts every entry, for when changes may have been missed.
+func (c *Cache) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	dropped := c.lru.Len()
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.byRow = make(map[Key]map[string]struct{})
+	c.stats.Invalidations += uint64(dropped)
+	return dropped
+}
+
+// Stats returns a snapshot of the counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+func (c *Cache) remove(element *list.Element) {
+	e := element.Value.(*entry)
+	c.lru.Remove(element)
+	delete(c.entries, e.name)
+	for _, row := range e.rows {
+		names := c.byRow[row]
+		delete(names, e.name)
+		if len(names) == 0 {
+			delete(c.byRow, row)
+		}
+	}
+}
+
+// ReadThrough returns the value cached under name, or loads it and caches it
+// with the rows reported by rows. The caller gets a copy made by clone, so
+// changing it leaves the cached value intact. A value loaded while an
+// invalidation ran is returned but not cached, as it may predate the write.
+func ReadThrough[V any](c *Cache, name string, load func() (V, error), rows func(V) []Key, clone func(V) V) (V, error) {
+	if value, exists := c.Get(name); exists {
+		return clone(value.(V)), nil
+	}
+	generation := c.currentGeneration()
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.setSince(generation, name, value, rows(value))
+	return clone(value), nil
+}
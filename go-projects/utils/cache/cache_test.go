@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheLRUAndTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := New(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	if _, exists := c.Get("b"); exists {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	if value, exists := c.Get("a"); !exists || value != 1 {
+		t.Errorf("Expected a to stay cached, got %v, %v", value, exists)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, exists := c.Get("c"); exists {
+		t.Error("Expected c to expire after the TTL")
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Evictions != 2 || stats.Size != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	c := New(10, 0)
+	c.Set("project/1", "graph", NewKey("PROJECTS", 1), NewKey("TASKS", 7), NewKey("RESOURCES", 3))
+	c.Set("resource/3", "row", NewKey("RESOURCES", 3))
+	c.Set("task/8", "row", NewKey("TASKS", 8))
+
+	if dropped := c.Invalidate(NewKey("resources", 3)); dropped != 2 {
+		t.Errorf("Expected both entries read from resource 3 to be dropped, got %d", dropped)
+	}
+	if dropped := c.InvalidateTable("tasks"); dropped != 1 {
+		t.Errorf("Expected the remaining task entry to be dropped, got %d", dropped)
+	}
+	if stats := c.Stats(); stats.Size != 0 || stats.Invalidations != 3 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	c.Set("project/2", "graph", NewKey("PROJECTS", 2))
+	if dropped := c.Purge(); dropped != 1 || c.Invalidate(NewKey("projects", 2)) != 0 {
+		t.Errorf("Expected Purge to drop the entry and its row index, got %d", dropped)
+	}
+}
+
+func TestReadThrough(t *testing.T) {
+	c := New(10, 0)
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return 42, nil
+	}
+	rows := func(int) []Key { return []Key{NewKey("projects", 1)} }
+
+	for i := 0; i < 3; i++ {
+		if value, err := ReadThrough(c, "project/1", load, rows, DeepCopy[int]); err != nil || value != 42 {
+			t.Fatalf("ReadThrough = %v, %v", value, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("Expected a single load, got %d", loads)
+	}
+
+	c.Invalidate(NewKey("projects", 1))
+	ReadThrough(c, "project/1", load, rows, DeepCopy[int])
+	if loads != 2 {
+		t.Errorf("Expected a reload after invalidation, got %d loads", loads)
+	}
+}
+
+func TestReadThroughSkipsValuesInvalidatedWhileLoading(t *testing.T) {
+	c := New(10, 0)
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		if loads == 1 {
+			// A write to the row lands between the read and the Set.
+			c.Invalidate(NewKey("projects", 1))
+		}
+		return loads, nil
+	}
+	rows := func(int) []Key { return []Key{NewKey("projects", 1)} }
+
+	if value, _ := ReadThrough(c, "project/1", load, rows, DeepCopy[int]); value != 1 {
+		t.Fatalf("Expected the loaded value, got %d", value)
+	}
+	if value, _ := ReadThrough(c, "project/1", load, rows, DeepCopy[int]); value != 2 || loads != 2 {
+		t.Errorf("Expected the stale value not to be cached, got %d after %d loads", value, loads)
+	}
+}
+
+func TestReadThroughReturnsCopies(t *testing.T) {
+	type task struct{ Name *string }
+	type project struct{ Tasks []task }
+	name := "task"
+	c := New(10, 0)
+	load := func() (*project, error) { return &project{Tasks: []task{{Name: &name}}}, nil }
+	rows := func(*project) []Key { return nil }
+
+	first, _ := ReadThrough(c, "project/1", load, rows, DeepCopy[*project])
+	*first.Tasks[0].Name = "changed"
+	first.Tasks = nil
+
+	second, _ := ReadThrough(c, "project/1", load, rows, DeepCopy[*project])
+	if second == first || len(second.Tasks) != 1 || *second.Tasks[0].Name != "task" {
+		t.Errorf("Expected changes to a read not to reach the cache, got %+v", second)
+	}
+}
+
+func TestProjectRows(t *testing.T) {
+	type resource struct{ ID int }
+	type task struct {
+		ID        int
+		Resources []*resource
+	}
+	type project struct {
+		ID    int
+		Tasks []task
+	}
+	rows := ProjectRows(&project{ID: 1, Tasks: []task{{ID: 7, Resources: []*resource{{ID: 3}}}}})
+	want := []Key{NewKey("projects", 1), NewKey("tasks", 7), NewKey("resources", 3)}
+	if len(rows) != len(want) || rows[0] != want[0] || rows[1] != want[1] || rows[2] != want[2] {
+		t.Errorf("ProjectRows = %v, want %v", rows, want)
+	}
+}
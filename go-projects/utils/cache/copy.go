@@ -0,0 +1,61 @@
+package cache
+
+import "reflect"
+
+// DeepCopy copies value and everything it points to: pointers, slices, maps
+// and the exported fields of structs, so a cached value handed out as a copy
+// can be changed without changing the cache. Unexported fields, such as the
+// location of a time.Time, are shared.
+func DeepCopy[V any](value V) V {
+	copied := reflect.New(reflect.TypeOf(&value).Elem()).Elem()
+	copied.Set(deepCopy(reflect.ValueOf(&value).Elem()))
+	return copied.Interface().(V)
+}
+
+func deepCopy(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.New(value.Type().Elem())
+		copied.Elem().Set(deepCopy(value.Elem()))
+		return copied
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(deepCopy(value.Index(i)))
+		}
+		return copied
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(deepCopy(value.Field(i)))
+			}
+		}
+		return copied
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(deepCopy(value.Elem()))
+		return copied
+	}
+	return value
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"fmt"
+	"m/utils/orderby"
+	"reflect"
+)
+
+// RepositoryFuncs are the functions of an approach that a Repository wraps:
+// R is its resource type and P its project type.
+type RepositoryFuncs[R, P any] struct {
+	InsertResource func(resource R) (int, error)
+	InsertProject  func(project P) (int, error)
+	ReadProject    func(projectID int, order ...orderby.OrderBy) (*P, error)
+	UpdateProject  func(project *P) error
+	DeleteProject  func(projectID int) error
+	// Rows lists the rows a project graph was read from. ProjectRows is used
+	// when it is nil.
+	Rows func(project *P) []Key
+}
+
+// Repository serves ReadProject from a read-through cache and evicts the
+// cached projects touched by its writes. Writes made through other
+// connections are not seen.
+type Repository[R, P any] struct {
+	name  string
+	cache *Cache
+	funcs RepositoryFuncs[R, P]
+}
+
+// NewRepository wraps funcs with c. name prefixes the cache entries, so that
+// approaches can share a cache.
+func NewRepository[R, P any](c *Cache, name string, funcs RepositoryFuncs[R, P]) *Repository[R, P] {
+	if funcs.Rows == nil {
+		funcs.Rows = ProjectRows[P]
+	}
+	return &Repository[R, P]{name: name, cache: c, funcs: funcs}
+}
+
+// Cache exposes the underlying cache and its counters.
+func (r *Repository[R, P]) Cache() *Cache {
+	return r.cache
+}
+
+func (r *Repository[R, P]) InsertResource(resource R) (int, error) {
+	return r.funcs.InsertResource(resource)
+}
+
+func (r *Repository[R, P]) InsertProject(project P) (int, error) {
+	id, err := r.funcs.InsertProject(project)
+	r.cache.Invalidate(r.funcs.Rows(&project)...)
+	return id, err
+}
+
+// ReadProject returns a copy of the cached project, which the caller may
+// change freely.
+func (r *Repository[R, P]) ReadProject(projectID int, order ...orderby.OrderBy) (*P, error) {
+	name := fmt.Sprintf("%s.ReadProject/%d/%v", r.name, projectID, orderby.Resolve(order))
+	return ReadThrough(r.cache, name, func() (*P, error) {
+		return r.funcs.ReadProject(projectID, order...)
+	}, r.funcs.Rows, DeepCopy[*P])
+}
+
+// UpdateProject evicts the project even when the update fails, since some of
+// its statements may already have been applied.
+func (r *Repository[R, P]) UpdateProject(project *P) error {
+	err := r.funcs.UpdateProject(project)
+	r.cache.Invalidate(r.funcs.Rows(project)...)
+	return err
+}
+
+func (r *Repository[R, P]) DeleteProject(projectID int) error {
+	err := r.funcs.DeleteProject(projectID)
+	r.cache.Invalidate(NewKey("projects", projectID))
+	return err
+}
+
+// ProjectRows lists the rows a project graph was read from: the project, the
+// Tasks of its ID and the Resources of each task, found by field name.
+func ProjectRows[P any](project *P) []Key {
+	value := reflect.ValueOf(project).Elem()
+	rows := []Key{NewKey("projects", int(value.FieldByName("ID").Int()))}
+	tasks := value.FieldByName("Tasks")
+	for i := 0; i < tasks.Len(); i++ {
+		task := reflect.Indirect(tasks.Index(i))
+		rows = append(rows, NewKey("tasks", int(task.FieldByName("ID").Int())))
+		resources := task.FieldByName("Resources")
+		for j := 0; j < resources.Len(); j++ {
+			resource := reflect.Indirect(resources.Index(j))
+			rows = append(rows, NewKey("resources", int(resource.FieldByName("ID").Int())))
+		}
+	}
+	return rows
+}
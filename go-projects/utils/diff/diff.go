@@ -0,0 +1,234 @@
+// Package diff compares two object graphs through their JSON encodings, so
+// that entities of different approaches compare by their JSON names, and
+// reports every difference with its path:
+//
+//	tasks[id=7].resources[id=3].dailyCost: 3.14 != 92.54
+//
+// Arrays whose elements are objects with distinct ids are matched by id
+// regardless of order; other arrays are compared by position. A missing key,
+// null and an empty array are the same. Strings that parse as RFC 3339 times
+// are equal when they are the same instant, or both midnight of the same date
+// whatever their zones, as DATE columns are read back.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Difference is a value that differs between the expected and the actual
+// graph.
+type Difference struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + d.Expected + " != " + d.Actual
+}
+
+// missing stands for a value that is absent from one side.
+const missing = "missing"
+
+// Compare returns the differences between expected and actual, sorted by
+// path. It fails only when either cannot be encoded to JSON.
+func Compare(expected, actual interface{}) ([]Difference, error) {
+	left, err := normalize(expected)
+	if err != nil {
+		return nil, fmt.Errorf("encoding expected: %v", err)
+	}
+	right, err := normalize(actual)
+	if err != nil {
+		return nil, fmt.Errorf("encoding actual: %v", err)
+	}
+
+	var differences []Difference
+	compare("", left, right, &differences)
+	sort.SliceStable(differences, func(i, j int) bool { return differences[i].Path < differences[j].Path })
+	return differences, nil
+}
+
+func normalize(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var decoded interface{}
+	err = decoder.Decode(&decoded)
+	return decoded, err
+}
+
+func compare(path string, expected, actual interface{}, differences *[]Difference) {
+	if isEmpty(expected) && isEmpty(actual) {
+		return
+	}
+
+	switch left := expected.(type) {
+	case map[string]interface{}:
+		if right, ok := actual.(map[string]interface{}); ok {
+			compareObjects(path, left, right, differences)
+			return
+		}
+	case []interface{}:
+		if right, ok := actual.([]interface{}); ok {
+			compareArrays(path, left, right, differences)
+			return
+		}
+	case json.Number:
+		if right, ok := actual.(json.Number); ok && equalNumbers(left, right) {
+			return
+		}
+	case string:
+		if right, ok := actual.(string); ok && equalStrings(left, right) {
+			return
+		}
+	case bool:
+		if right, ok := actual.(bool); ok && left == right {
+			return
+		}
+	}
+	*differences = append(*differences, Difference{Path: path, Expected: format(expected), Actual: format(actual)})
+}
+
+func compareObjects(path string, expected, actual map[string]interface{}, differences *[]Difference) {
+	keys := make(map[string]bool)
+	for key := range expected {
+		keys[key] = true
+	}
+	for key := range actual {
+		keys[key] = true
+	}
+	for key := range keys {
+		compare(join(path, key), expected[key], actual[key], differences)
+	}
+}
+
+func compareArrays(path string, expected, actual []interface{}, differences *[]Difference) {
+	left, leftOK := byID(expected)
+	right, rightOK := byID(actual)
+	if !leftOK || !rightOK {
+		for i := 0; i < len(expected) || i < len(actual); i++ {
+			compare(path+"["+strconv.Itoa(i)+"]", element(expected, i), element(actual, i), differences)
+		}
+		return
+	}
+
+	for id, item := range left {
+		elementPath := path + "[id=" + id + "]"
+		other, ok := right[id]
+		if !ok {
+			*differences = append(*differences, Difference{Path: elementPath, Expected: format(item), Actual: missing})
+			continue
+		}
+		compare(elementPath, item, other, differences)
+	}
+	for id, item := range right {
+		if _, ok := left[id]; !ok {
+			*differences = append(*differences, Difference{Path: path + "[id=" + id + "]", Expected: missing, Actual: format(item)})
+		}
+	}
+}
+
+// byID indexes the elements of items by their id, when all of them are
+// objects with distinct ids.
+func byID(items []interface{}) (map[string]interface{}, bool) {
+	indexed := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		id, ok := object["id"]
+		if !ok || id == nil {
+			return nil, false
+		}
+		key := format(id)
+		if _, duplicate := indexed[key]; duplicate {
+			return nil, false
+		}
+		indexed[key] = object
+	}
+	return indexed, true
+}
+
+func element(items []interface{}, i int) interface{} {
+	if i < len(items) {
+		return items[i]
+	}
+	return nil
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	items, ok := value.([]interface{})
+	return ok && len(items) == 0
+}
+
+func equalNumbers(expected, actual json.Number) bool {
+	if expected == actual {
+		return true
+	}
+	left, leftErr := expected.Float64()
+	right, rightErr := actual.Float64()
+	return leftErr == nil && rightErr == nil && left == right
+}
+
+func equalStrings(expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	left, leftErr := time.Parse(time.RFC3339Nano, expected)
+	right, rightErr := time.Parse(time.RFC3339Nano, actual)
+	if leftErr != nil || rightErr != nil {
+		return false
+	}
+	if left.Equal(right) {
+		return true
+	}
+	return isMidnight(left) && isMidnight(right) &&
+		left.Year() == right.Year() && left.YearDay() == right.YearDay()
+}
+
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// format renders a value of the path messages: scalars as JSON, objects and
+// arrays by their size.
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		if id, ok := v["id"]; ok {
+			return "{id=" + format(id) + "}"
+		}
+		return fmt.Sprintf("{%d keys}", len(v))
+	case []interface{}:
+		return fmt.Sprintf("[%d items]", len(v))
+	case json.Number:
+		return v.String()
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type resource struct {
+	ID        int      `json:"id"`
+	DailyCost *float64 `json:"dailyCost"`
+}
+
+type task struct {
+	ID        int        `json:"id"`
+	Deadline  time.Time  `json:"deadline"`
+	Resources []resource `json:"resources"`
+}
+
+type project struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Tasks []task `json:"tasks"`
+}
+
+// readProject has an extra field, like the GORM entities.
+type readProject struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Tasks []task  `json:"tasks"`
+	Extra *string `json:"extra"`
+}
+
+func cost(value float64) *float64 {
+	return &value
+}
+
+func TestCompareEqual(t *testing.T) {
+	zone := time.FixedZone("UTC-3", -3*60*60)
+	expected := project{ID: 1, Name: "p", Tasks: []task{
+		{ID: 7, Deadline: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), Resources: []resource{{ID: 3, DailyCost: cost(3.14)}, {ID: 4}}},
+		{ID: 8, Deadline: time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC)},
+	}}
+	actual := readProject{ID: 1, Name: "p", Tasks: []task{
+		{ID: 8, Deadline: time.Date(2024, 3, 11, 9, 0, 0, 0, zone), Resources: []resource{}},
+		{ID: 7, Deadline: time.Date(2024, 3, 10, 0, 0, 0, 0, zone), Resources: []resource{{ID: 4}, {ID: 3, DailyCost: cost(3.14)}}},
+	}}
+
+	differences, err := Compare(expected, actual)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if len(differences) != 0 {
+		t.Errorf("Expected reordered tasks, empty slices, extra nulls and the same dates to match, got %v", differences)
+	}
+}
+
+func TestCompareDifferences(t *testing.T) {
+	expected := project{ID: 1, Name: "p", Tasks: []task{
+		{ID: 7, Resources: []resource{{ID: 3, DailyCost: cost(3.14)}}},
+		{ID: 8},
+	}}
+	actual := project{ID: 1, Name: "q", Tasks: []task{
+		{ID: 7, Deadline: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), Resources: []resource{{ID: 3, DailyCost: cost(92.54)}}},
+		{ID: 9},
+	}}
+
+	differences, err := Compare(expected, actual)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var messages []string
+	for _, difference := range differences {
+		messages = append(messages, difference.String())
+	}
+	expectedMessages := []string{
+		`name: "p" != "q"`,
+		`tasks[id=7].deadline: "0001-01-01T00:00:00Z" != "2024-03-10T00:00:00Z"`,
+		`tasks[id=7].resources[id=3].dailyCost: 3.14 != 92.54`,
+		`tasks[id=8]: {id=8} != missing`,
+		`tasks[id=9]: missing != {id=9}`,
+	}
+	if !reflect.DeepEqual(messages, expectedMessages) {
+		t.Errorf("Expected %q, got %q", expectedMessages, messages)
+	}
+}
+
+func TestComparePositional(t *testing.T) {
+	differences, err := Compare([]string{"a", "b"}, []string{"a", "c", "d"})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if len(differences) != 2 || differences[0].String() != `[1]: "b" != "c"` || differences[1].String() != `[2]: null != "d"` {
+		t.Errorf("Expected arrays without ids to be compared by position, got %v", differences)
+	}
+}
@@ -0,0 +1,231 @@
+// Package explain captures the execution plan of every distinct statement
+// reported by querylog, with EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON), and
+// flags the sequential scans that point to a missing index.
+package explain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"m/utils/querylog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Watch selects the sequential scans to flag: those on Relation whose filter
+// or join condition mentions Column, or any scan on Relation if Column is empty.
+type Watch struct {
+	Relation string `json:"relation"`
+	Column   string `json:"column,omitempty"`
+}
+
+// DefaultWatches are the scans that the schema indexes should prevent.
+var DefaultWatches = []Watch{
+	{Relation: "tasks", Column: "project_id"},
+	{Relation: "task_resource"},
+}
+
+// Flag is a sequential scan matched by a Watch.
+type Flag struct {
+	Watch
+	Condition string `json:"condition,omitempty"`
+}
+
+// Plan is the captured plan of one statement. Analyzed is false when the
+// statement could not be executed again, for example an INSERT whose key now
+// exists; the plan is then the estimate of a plain EXPLAIN and Error says why.
+type Plan struct {
+	Approach  string          `json:"approach"`
+	Operation string          `json:"operation"`
+	Query     string          `json:"query"`
+	Analyzed  bool            `json:"analyzed"`
+	Error     string          `json:"error,omitempty"`
+	Flags     []Flag          `json:"flags,omitempty"`
+	Plan      json.RawMessage `json:"plan,omitempty"`
+}
+
+type statement struct {
+	approach, operation string
+	query               string
+	args                []interface{}
+}
+
+// Capturer is a querylog.Logger that records the first occurrence of each
+// statement and explains it on Flush, on its own connection so that neither
+// the plans nor their queries are logged or counted.
+type Capturer struct {
+	db      *sql.DB
+	watches []Watch
+
+	mu                  sync.Mutex
+	approach, operation string
+	seen                map[string]bool
+	pending             []statement
+	plans               []Plan
+}
+
+// New creates a capturer that explains on db; nil watches selects
+// DefaultWatches.
+func New(db *sql.DB, watches []Watch) *Capturer {
+	if watches == nil {
+		watches = DefaultWatches
+	}
+	return &Capturer{db: db, watches: watches, seen: make(map[string]bool)}
+}
+
+// SetScope labels the statements recorded from now on.
+func (c *Capturer) SetScope(approach, operation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.approach, c.operation = approach, operation
+}
+
+func (c *Capturer) LogQuery(_ context.Context, entry querylog.Entry) {
+	if entry.Err != nil || (entry.Op != "query" && entry.Op != "exec") || !explainable(entry.Query) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := shape(entry.Query)
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.pending = append(c.pending, statement{c.approach, c.operation, entry.Query, entry.Args})
+}
+
+func (c *Capturer) IgnoresCaller() bool {
+	return true
+}
+
+var (
+	literal = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b|(?i:\bnull\b|\btrue\b|\bfalse\b)`)
+	list    = regexp.MustCompile(`\?(?:\s*,\s*\?)+`)
+	tuples  = regexp.MustCompile(`\(\?\)(?:\s*,\s*\(\?\))+`)
+	spacing = regexp.MustCompile(`\s+`)
+)
+
+// shape identifies the statements that differ only in their values: GORM
+// reports its statements with the arguments already interpolated, so the
+// literals, the lists of them and the rows of a multi-row VALUES are reduced
+// to a single placeholder.
+func shape(query string) string {
+	query = literal.ReplaceAllString(query, "?")
+	query = list.ReplaceAllString(query, "?")
+	query = tuples.ReplaceAllString(query, "(?)")
+	return strings.TrimSpace(spacing.ReplaceAllString(query, " "))
+}
+
+// explainable reports whether EXPLAIN accepts the statement.
+func explainable(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(strings.TrimLeft(fields[0], "(")) {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "VALUES":
+		return true
+	}
+	return false
+}
+
+// Flush explains the statements recorded since the last call. Statements run
+// again inside a transaction that is rolled back, so writes leave no trace.
+// The plans reflect the data present when Flush runs.
+func (c *Capturer) Flush() {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	for _, stmt := range pending {
+		plan := c.explain(stmt)
+
+		c.mu.Lock()
+		c.plans = append(c.plans, plan)
+		c.mu.Unlock()
+	}
+}
+
+func (c *Capturer) explain(stmt statement) Plan {
+	plan := Plan{Approach: stmt.approach, Operation: stmt.operation, Query: stmt.query}
+
+	raw, err := c.analyze(stmt)
+	if err != nil {
+		plan.Error = err.Error()
+		if err := c.db.QueryRow("EXPLAIN (FORMAT JSON) "+stmt.query, stmt.args...).Scan(&raw); err != nil {
+			plan.Error = err.Error()
+			return plan
+		}
+	} else {
+		plan.Analyzed = true
+	}
+
+	plan.Plan = raw
+	plan.Flags, err = Scans(raw, c.watches)
+	if err != nil {
+		plan.Error = err.Error()
+	}
+	return plan
+}
+
+func (c *Capturer) analyze(stmt statement) (json.RawMessage, error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A row locked by an open transaction of the benchmark must not stall Flush.
+	if _, err := tx.Exec("SET LOCAL lock_timeout = '1s'"); err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	err = tx.QueryRow("EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "+stmt.query, stmt.args...).Scan(&raw)
+	return raw, err
+}
+
+// Plans returns the plans explained so far, in the order the statements were
+// first seen.
+func (c *Capturer) Plans() []Plan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Plan(nil), c.plans...)
+}
+
+func (c *Capturer) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c.Plans())
+}
+
+// DumpJSON replaces the file at path with the plans explained so far.
+func (c *Capturer) DumpJSON(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadFile loads the plans written by DumpJSON.
+func ReadFile(path string) ([]Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plans []Plan
+	err = json.Unmarshal(data, &plans)
+	return plans, err
+}
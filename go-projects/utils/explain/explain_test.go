@@ -0,0 +1,88 @@
+package explain
+
+import (
+	"context"
+	"m/utils/querylog"
+	"testing"
+)
+
+const hashJoinPlan = `[{"Plan": {
+	"Node Type": "Hash Join",
+	"Hash Cond": "(t.project_id = p.id)",
+	"Plans": [
+		{"Node Type": "Index Scan", "Relation Name": "projects", "Index Cond": "(id = 1)"},
+		{"Node Type": "Hash", "Plans": [
+			{"Node Type": "Seq Scan", "Relation Name": "tasks"}
+		]}
+	]
+}}]`
+
+func TestScans(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     string
+		expected []Flag
+	}{
+		{"filter", `[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "tasks", "Filter": "(project_id = 1)"}}]`,
+			[]Flag{{Watch: DefaultWatches[0], Condition: "(project_id = 1)"}}},
+		{"join condition", hashJoinPlan,
+			[]Flag{{Watch: DefaultWatches[0], Condition: "(t.project_id = p.id)"}}},
+		{"other column", `[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "tasks", "Filter": "(id = 1)"}}]`, nil},
+		{"any scan", `[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "task_resource"}}]`,
+			[]Flag{{Watch: DefaultWatches[1]}}},
+		{"index scan", `[{"Plan": {"Node Type": "Index Scan", "Relation Name": "task_resource"}}]`, nil},
+	}
+	for _, test := range tests {
+		flags, err := Scans([]byte(test.plan), DefaultWatches)
+		if err != nil {
+			t.Fatalf("%s: Scans failed: %v", test.name, err)
+		}
+		if len(flags) != len(test.expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, flags)
+			continue
+		}
+		for i := range flags {
+			if flags[i] != test.expected[i] {
+				t.Errorf("%s: expected %+v, got %+v", test.name, test.expected[i], flags[i])
+			}
+		}
+	}
+}
+
+func TestShape(t *testing.T) {
+	same := []string{
+		`INSERT INTO "tasks" ("name","deadline","project_id") VALUES ('Design',  '2024-01-02 00:00:00',1),('It''s done',NULL,1) RETURNING "id"`,
+		`INSERT INTO "tasks" ("name","deadline","project_id") VALUES ('Build','2024-03-04 00:00:00',12) RETURNING "id"`,
+	}
+	if shape(same[0]) != shape(same[1]) {
+		t.Errorf("Expected the same shape, got %q and %q", shape(same[0]), shape(same[1]))
+	}
+	if got := shape(`SELECT * FROM "resources" WHERE "resources"."id" IN (1,2,3) AND t1.x = 4.5`); got != `SELECT * FROM "resources" WHERE "resources"."id" IN (?) AND t1.x = ?` {
+		t.Errorf("Unexpected shape %q", got)
+	}
+}
+
+func TestLogQuery(t *testing.T) {
+	capturer := New(nil, nil)
+	ctx := context.Background()
+
+	capturer.SetScope("DirectStruct", "ReadProject")
+	capturer.LogQuery(ctx, querylog.Entry{Op: "query", Query: "SELECT * FROM PROJECTS WHERE ID = $1", Args: []interface{}{1}})
+	capturer.LogQuery(ctx, querylog.Entry{Op: "query", Query: "SELECT * FROM PROJECTS WHERE ID = $1", Args: []interface{}{2}})
+	capturer.LogQuery(ctx, querylog.Entry{Op: "query", Query: `SELECT * FROM "projects" WHERE "projects"."id" = 3`})
+	capturer.LogQuery(ctx, querylog.Entry{Op: "query", Query: `SELECT * FROM "projects" WHERE "projects"."id" = 4`})
+	capturer.LogQuery(ctx, querylog.Entry{Op: "prepare", Query: "SELECT 1"})
+	capturer.LogQuery(ctx, querylog.Entry{Op: "exec", Query: "SET search_path TO public"})
+	capturer.SetScope("DirectStruct", "DeleteProject")
+	capturer.LogQuery(ctx, querylog.Entry{Op: "exec", Query: "  DELETE FROM PROJECTS WHERE ID = $1", Args: []interface{}{1}})
+
+	if len(capturer.pending) != 3 {
+		t.Fatalf("Expected 3 distinct statements, got %+v", capturer.pending)
+	}
+	if first := capturer.pending[0]; first.operation != "ReadProject" || first.args[0] != 1 {
+		t.Errorf("Expected the first occurrence to be kept, got %+v", first)
+	}
+	if last := capturer.pending[2]; last.operation != "DeleteProject" {
+		t.Errorf("Unexpected last statement: %+v", last)
+	}
+}
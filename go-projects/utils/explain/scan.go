@@ -0,0 +1,68 @@
+package explain
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// node is the part of a FORMAT JSON plan node that Scans reads.
+type node struct {
+	NodeType     string `json:"Node Type"`
+	RelationName string `json:"Relation Name"`
+	Filter       string `json:"Filter"`
+	JoinFilter   string `json:"Join Filter"`
+	HashCond     string `json:"Hash Cond"`
+	MergeCond    string `json:"Merge Cond"`
+	Plans        []node `json:"Plans"`
+}
+
+func (n node) conditions() []string {
+	return []string{n.Filter, n.JoinFilter, n.HashCond, n.MergeCond}
+}
+
+// Scans returns the sequential scans of a FORMAT JSON plan matched by watches.
+// The condition of a scan is its own filter, or the condition of a join above
+// it, where a hash or merge join puts it.
+func Scans(raw []byte, watches []Watch) ([]Flag, error) {
+	var plans []struct {
+		Plan node `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &plans); err != nil {
+		return nil, err
+	}
+
+	var flags []Flag
+	for _, plan := range plans {
+		flags = scans(plan.Plan, nil, watches, flags)
+	}
+	return flags, nil
+}
+
+func scans(n node, inherited []string, watches []Watch, flags []Flag) []Flag {
+	if n.NodeType == "Seq Scan" {
+		for _, watch := range watches {
+			if !strings.EqualFold(n.RelationName, watch.Relation) {
+				continue
+			}
+			if condition, ok := mentions(append(n.conditions(), inherited...), watch.Column); ok {
+				flags = append(flags, Flag{Watch: watch, Condition: condition})
+			}
+		}
+	}
+	inherited = append(n.conditions(), inherited...)
+	for _, child := range n.Plans {
+		flags = scans(child, inherited, watches, flags)
+	}
+	return flags
+}
+
+// mentions returns the first condition naming column; an empty column
+// matches without a condition.
+func mentions(conditions []string, column string) (string, bool) {
+	for _, condition := range conditions {
+		if condition != "" && (column == "" || strings.Contains(strings.ToLower(condition), strings.ToLower(column))) {
+			return condition, true
+		}
+	}
+	return "", column == ""
+}
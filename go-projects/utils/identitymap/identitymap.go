@@ -0,0 +1,100 @@
+// Package identitymap keeps one instance per database row for the lifetime of
+// a session, keyed by table and primary key.
+package identitymap
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Key identifies a row. Tables are compared case-insensitively and integer
+// keys of any width are equal, so a key scanned from the driver as int64
+// matches one passed by the caller as int.
+type Key struct {
+	Table string
+	ID    interface{}
+}
+
+func NewKey(table string, id interface{}) Key {
+	return Key{Table: strings.ToLower(table), ID: normalize(id)}
+}
+
+func normalize(id interface{}) interface{} {
+	switch v := id.(type) {
+	case []byte:
+		return string(v)
+	case int64, string:
+		return v
+	}
+	value := reflect.ValueOf(id)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+	}
+	return id
+}
+
+// Map is a session identity map. Entities are never refreshed once stored:
+// a new session is needed to observe changes made elsewhere.
+type Map struct {
+	mu       sync.Mutex
+	entities map[Key]interface{}
+}
+
+func New() *Map {
+	return &Map{entities: make(map[Key]interface{})}
+}
+
+// Lookup returns the instance stored for a row, if any.
+func (m *Map) Lookup(table string, id interface{}) (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entity, exists := m.entities[NewKey(table, id)]
+	return entity, exists
+}
+
+// Store keeps entity for a row unless one is already known, and returns the
+// instance callers should use.
+func (m *Map) Store(table string, id interface{}, entity interface{}) interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := NewKey(table, id)
+	if existing, exists := m.entities[key]; exists {
+		return existing
+	}
+	m.entities[key] = entity
+	return entity
+}
+
+// Resolve returns the instance known for a row or stores the one built by load.
+func (m *Map) Resolve(table string, id interface{}, load func() (interface{}, error)) (interface{}, error) {
+	if entity, exists := m.Lookup(table, id); exists {
+		return entity, nil
+	}
+	entity, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return m.Store(table, id, entity), nil
+}
+
+// Evict forgets a row, so the next load reads it again.
+func (m *Map) Evict(table string, id interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entities, NewKey(table, id))
+}
+
+// Len returns the number of rows held by the map.
+func (m *Map) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.entities)
+}
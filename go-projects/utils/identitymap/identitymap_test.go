@@ -0,0 +1,23 @@
+package identitymap
+
+import "testing"
+
+func TestKeysNormalizeTableAndIntegerWidth(t *testing.T) {
+	m := New()
+	resource := &struct{ ID int }{ID: 3}
+
+	if got := m.Store("RESOURCES", int64(3), resource); got != resource {
+		t.Fatal("Expected the first stored instance to be returned")
+	}
+	if got, exists := m.Lookup("resources", 3); !exists || got != resource {
+		t.Errorf("Expected lookup by int to find the instance stored by int64")
+	}
+	if got := m.Store("resources", int32(3), &struct{ ID int }{ID: 3}); got != resource {
+		t.Errorf("Expected Store to keep the instance already known")
+	}
+
+	m.Evict("Resources", 3)
+	if m.Len() != 0 {
+		t.Errorf("Expected the map to be empty after eviction, got %d", m.Len())
+	}
+}
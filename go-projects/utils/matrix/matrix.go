@@ -0,0 +1,237 @@
+// Package matrix describes which benchmarks cmd/main.go runs, and expands
+// that description into one go test invocation per combination of approach,
+// dataset and pool settings, plus one of the shared suite in tests/suite.
+package matrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"m/utils/results"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config selects the benchmarks to run. Empty fields select the defaults:
+// every approach, every operation, tests/input.json, the driver's pool size
+// and GOMAXPROCS.
+type Config struct {
+	Approaches []string `json:"approaches"` // directories under tests
+	Operations []string `json:"operations"` // benchmark names without the Benchmark prefix
+	Datasets   []string `json:"datasets"`   // input files in the format of tests/input.json, or gen: specs
+	PoolSizes  []int    `json:"poolSizes"`  // maximum open connections
+	IdleConns  []int    `json:"idleConns"`  // maximum idle connections, the pool size by default
+	Lifetimes  []string `json:"lifetimes"`  // maximum connection lifetimes, such as 30s
+	CPUs       []int    `json:"cpus"`       // GOMAXPROCS values, passed to go test -cpu
+	Count      int      `json:"count"`      // go test -count
+	Benchtime  string   `json:"benchtime"`  // go test -benchtime, such as 2s or 500x
+}
+
+// DefaultApproaches are the directories under tests with benchmarks.
+var DefaultApproaches = []string{"DAONotation", "DirectStruct", "GORM", "PGX", "SQLGen", "SQLRepository", "StoredProcedure"}
+
+// Suite is the directory under tests of the benchmarks shared by every
+// approach, which runs each of them as a sub-benchmark named after it.
+const Suite = "suite"
+
+// Load reads a JSON config file.
+func Load(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// Job is one go test invocation of the matrix. CPUs and Count are left to go
+// test, which reports the CPU count in each result name.
+type Job struct {
+	Approach  string // or Suite
+	Dataset   string // path of the input file, empty for the default
+	PoolSize  int    // 0 for the driver default
+	IdleConns int    // 0 for the pool size
+	Lifetime  string // empty for the driver default
+	Config    *Config
+}
+
+// Expand returns the jobs of the config: the suite, then the benchmarks
+// specific to every approach, each with every dataset and every combination
+// of pool size, idle connections and lifetime.
+func (c *Config) Expand() []Job {
+	approaches := c.Approaches
+	if len(approaches) == 0 {
+		approaches = DefaultApproaches
+	}
+	datasets := c.Datasets
+	if len(datasets) == 0 {
+		datasets = []string{""}
+	}
+	pools := c.PoolSizes
+	if len(pools) == 0 {
+		pools = []int{0}
+	}
+	idles := c.IdleConns
+	if len(idles) == 0 {
+		idles = []int{0}
+	}
+	lifetimes := c.Lifetimes
+	if len(lifetimes) == 0 {
+		lifetimes = []string{""}
+	}
+
+	var jobs []Job
+	for _, approach := range append([]string{Suite}, approaches...) {
+		for _, dataset := range datasets {
+			for _, pool := range pools {
+				for _, idle := range idles {
+					for _, lifetime := range lifetimes {
+						jobs = append(jobs, Job{Approach: approach, Dataset: dataset, PoolSize: pool,
+							IdleConns: idle, Lifetime: lifetime, Config: c})
+					}
+				}
+			}
+		}
+	}
+	return jobs
+}
+
+// Dir is the directory of the job's approach, relative to go-projects.
+func (j Job) Dir() string {
+	return filepath.Join("tests", j.Approach)
+}
+
+// Args are the go test arguments of the job.
+func (j Job) Args() []string {
+	pattern := BenchPattern(j.Config.Operations)
+	if j.Approach == Suite && len(j.Config.Approaches) > 0 {
+		pattern += "/" + namePattern(j.Config.Approaches)
+	}
+	args := []string{"test", "-benchmem", "-run=^_test$", "-bench", pattern}
+	if j.Config.Count > 0 {
+		args = append(args, "-count", strconv.Itoa(j.Config.Count))
+	}
+	if j.Config.Benchtime != "" {
+		args = append(args, "-benchtime", j.Config.Benchtime)
+	}
+	if len(j.Config.CPUs) > 0 {
+		cpus := make([]string, len(j.Config.CPUs))
+		for i, cpu := range j.Config.CPUs {
+			cpus[i] = strconv.Itoa(cpu)
+		}
+		args = append(args, "-cpu", strings.Join(cpus, ","))
+	}
+	return append(args, "./...")
+}
+
+// ResultApproach is the approach that the results of the job belong to, or
+// empty for the suite, whose results name it.
+func (j Job) ResultApproach() string {
+	if j.Approach == Suite {
+		return ""
+	}
+	return j.Approach
+}
+
+// Env are the variables through which the base package reads the dataset
+// and the pool settings of the job.
+func (j Job) Env() ([]string, error) {
+	var env []string
+	switch {
+	case strings.HasPrefix(j.Dataset, generatedPrefix):
+		env = append(env, "DATASET="+j.Dataset)
+	case j.Dataset != "":
+		path, err := filepath.Abs(j.Dataset)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, "DATASET="+path)
+	}
+	if j.PoolSize > 0 {
+		env = append(env, "POOL_SIZE="+strconv.Itoa(j.PoolSize))
+	}
+	if j.IdleConns > 0 {
+		env = append(env, "POOL_IDLE="+strconv.Itoa(j.IdleConns))
+	}
+	if j.Lifetime != "" {
+		if _, err := time.ParseDuration(j.Lifetime); err != nil {
+			return nil, fmt.Errorf("invalid lifetime %q: %v", j.Lifetime, err)
+		}
+		env = append(env, "POOL_LIFETIME="+j.Lifetime)
+	}
+	return env, nil
+}
+
+// Params label the results of the job with the parameters that vary beyond
+// the approach, operation and CPU count. Defaults are left out, so results of
+// a plain run keep their names.
+func (j Job) Params() map[string]string {
+	params := make(map[string]string)
+	if j.Dataset != "" {
+		params["dataset"] = DatasetName(j.Dataset)
+	}
+	if j.PoolSize > 0 {
+		params["pool"] = strconv.Itoa(j.PoolSize)
+	}
+	if j.IdleConns > 0 {
+		params["idle"] = strconv.Itoa(j.IdleConns)
+	}
+	if j.Lifetime != "" {
+		params["lifetime"] = j.Lifetime
+	}
+	if j.Config.Benchtime != "" {
+		params["benchtime"] = j.Config.Benchtime
+	}
+	return params
+}
+
+// Label names the job, for messages and per-job files: "GORM",
+// "GORM_dataset=large_pool=4".
+func (j Job) Label() string {
+	label := j.Approach
+	for _, param := range results.FormatParams(j.Params()) {
+		label += "_" + param
+	}
+	return label
+}
+
+// generatedPrefix marks the datasets generated by the base package instead of
+// read from a file, such as "gen:scale=4,seed=7". See base.ParseDatasetSpec.
+const generatedPrefix = "gen:"
+
+// DatasetName is the file name of a dataset without its extension, or the
+// settings of a generated dataset.
+func DatasetName(dataset string) string {
+	if strings.HasPrefix(dataset, generatedPrefix) {
+		return strings.TrimPrefix(dataset, generatedPrefix)
+	}
+	return strings.TrimSuffix(filepath.Base(dataset), filepath.Ext(dataset))
+}
+
+// BenchPattern is the go test -bench pattern that selects exactly the named
+// operations, or every benchmark when there are none.
+func BenchPattern(operations []string) string {
+	if len(operations) == 0 {
+		return "."
+	}
+	trimmed := make([]string, len(operations))
+	for i, operation := range operations {
+		trimmed[i] = strings.TrimPrefix(operation, "Benchmark")
+	}
+	return "^Benchmark" + strings.TrimPrefix(namePattern(trimmed), "^")
+}
+
+// namePattern matches exactly one of names: "^(GORM|DAONotation)$".
+func namePattern(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
@@ -0,0 +1,112 @@
+package matrix
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	config := Config{
+		Approaches: []string{"GORM", "DirectStruct"},
+		Operations: []string{"ReadProject", "BenchmarkInsertProject"},
+		Datasets:   []string{"datasets/small.json", "datasets/large.json"},
+		PoolSizes:  []int{2, 10},
+		CPUs:       []int{1, 4},
+		Count:      5,
+		Benchtime:  "2s",
+	}
+	jobs := config.Expand()
+	if len(jobs) != 12 {
+		t.Fatalf("Expected the suite and 2 approaches x 2 datasets x 2 pools, got %d jobs", len(jobs))
+	}
+
+	suite := jobs[3]
+	if suite.Approach != Suite || suite.ResultApproach() != "" || suite.Dir() != filepath.Join("tests", "suite") {
+		t.Fatalf("Expected the suite first, got %+v", suite)
+	}
+	if pattern := suite.Args()[4]; pattern != "^Benchmark(ReadProject|InsertProject)$/^(GORM|DirectStruct)$" {
+		t.Errorf("Expected the suite to select the approaches, got %q", pattern)
+	}
+
+	job := jobs[7]
+	if job.Approach != "GORM" || job.Dataset != "datasets/large.json" || job.PoolSize != 10 {
+		t.Fatalf("Unexpected job order: %+v", job)
+	}
+	expectedArgs := []string{"test", "-benchmem", "-run=^_test$", "-bench", "^Benchmark(ReadProject|InsertProject)$",
+		"-count", "5", "-benchtime", "2s", "-cpu", "1,4", "./..."}
+	if !reflect.DeepEqual(job.Args(), expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, job.Args())
+	}
+	env, err := job.Env()
+	if err != nil {
+		t.Fatalf("Env failed: %v", err)
+	}
+	absolute, _ := filepath.Abs("datasets/large.json")
+	if !reflect.DeepEqual(env, []string{"DATASET=" + absolute, "POOL_SIZE=10"}) {
+		t.Errorf("Unexpected env: %v", env)
+	}
+	if job.ResultApproach() != "GORM" || job.Label() != "GORM_benchtime=2s_dataset=large_pool=10" || job.Dir() != filepath.Join("tests", "GORM") {
+		t.Errorf("Unexpected label %q or dir %q", job.Label(), job.Dir())
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	config := Config{}
+	jobs := config.Expand()
+	if len(jobs) != len(DefaultApproaches)+1 {
+		t.Fatalf("Expected the suite and one job per approach, got %+v", jobs)
+	}
+	if args := jobs[0].Args(); !reflect.DeepEqual(args, []string{"test", "-benchmem", "-run=^_test$", "-bench", ".", "./..."}) {
+		t.Errorf("Expected the arguments of a plain run, got %v", args)
+	}
+	if env, _ := jobs[0].Env(); len(env) != 0 || len(jobs[0].Params()) != 0 {
+		t.Errorf("Expected no env or params by default, got %v and %v", env, jobs[0].Params())
+	}
+}
+
+func TestPoolSweep(t *testing.T) {
+	config := Config{Approaches: []string{"GORM"}, PoolSizes: []int{4}, IdleConns: []int{1, 4}, Lifetimes: []string{"30s", "5m"}}
+	jobs := config.Expand()
+	if len(jobs) != 8 {
+		t.Fatalf("Expected the suite and GORM with 2 idle counts x 2 lifetimes, got %d jobs", len(jobs))
+	}
+
+	job := jobs[5]
+	env, err := job.Env()
+	if err != nil {
+		t.Fatalf("Env failed: %v", err)
+	}
+	if !reflect.DeepEqual(env, []string{"POOL_SIZE=4", "POOL_IDLE=1", "POOL_LIFETIME=5m"}) {
+		t.Errorf("Unexpected env: %v", env)
+	}
+	if job.Label() != "GORM_idle=1_lifetime=5m_pool=4" {
+		t.Errorf("Unexpected label %q", job.Label())
+	}
+
+	job.Lifetime = "forever"
+	if _, err := job.Env(); err == nil {
+		t.Errorf("Expected an invalid lifetime to be rejected")
+	}
+}
+
+func TestGeneratedDataset(t *testing.T) {
+	job := Job{Approach: "GORM", Dataset: "gen:scale=4,seed=7", Config: &Config{}}
+	env, err := job.Env()
+	if err != nil || !reflect.DeepEqual(env, []string{"DATASET=gen:scale=4,seed=7"}) {
+		t.Errorf("Expected the spec to be passed unchanged, got %v (%v)", env, err)
+	}
+	if job.Params()["dataset"] != "scale=4,seed=7" {
+		t.Errorf("Unexpected params: %v", job.Params())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	config, err := Load("../../cmd/matrix.example.json")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if config.Count != 5 || len(config.PoolSizes) != 2 || config.Benchtime != "2s" {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+}
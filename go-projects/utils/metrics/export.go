@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QuerySeries are the figures of one approach, operation and statement kind.
+type QuerySeries struct {
+	Scope
+	Op       string    `json:"op"`
+	Count    uint64    `json:"count"`
+	Duration Histogram `json:"durationSeconds"`
+}
+
+type ErrorSeries struct {
+	Scope
+	Type  string `json:"type"`
+	Count uint64 `json:"count"`
+}
+
+// Snapshot is a copy of everything collected, in a stable order.
+type Snapshot struct {
+	Queries []QuerySeries        `json:"queries"`
+	Errors  []ErrorSeries        `json:"errors"`
+	Pools   map[string]PoolStats `json:"pools"`
+}
+
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := Snapshot{Pools: make(map[string]PoolStats, len(c.pools))}
+	for key, count := range c.queries {
+		histogram := *c.durations[key]
+		histogram.Counts = append([]uint64(nil), histogram.Counts...)
+		snapshot.Queries = append(snapshot.Queries, QuerySeries{Scope: key.Scope, Op: key.Op, Count: count, Duration: histogram})
+	}
+	for key, count := range c.errors {
+		snapshot.Errors = append(snapshot.Errors, ErrorSeries{Scope: key.Scope, Type: key.Type, Count: count})
+	}
+	for name, db := range c.pools {
+		snapshot.Pools[name] = poolStats(db)
+	}
+
+	sort.Slice(snapshot.Queries, func(i, j int) bool {
+		a, b := snapshot.Queries[i], snapshot.Queries[j]
+		return lessScope(a.Scope, b.Scope) || (a.Scope == b.Scope && a.Op < b.Op)
+	})
+	sort.Slice(snapshot.Errors, func(i, j int) bool {
+		a, b := snapshot.Errors[i], snapshot.Errors[j]
+		return lessScope(a.Scope, b.Scope) || (a.Scope == b.Scope && a.Type < b.Type)
+	})
+	return snapshot
+}
+
+func lessScope(a, b Scope) bool {
+	if a.Approach != b.Approach {
+		return a.Approach < b.Approach
+	}
+	return a.Operation < b.Operation
+}
+
+// WriteJSON writes the snapshot as indented JSON.
+func (c *Collector) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c.Snapshot())
+}
+
+// DumpJSON replaces the file at path with the current snapshot.
+func (c *Collector) DumpJSON(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WritePrometheus(w)
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	snapshot := c.Snapshot()
+	var out strings.Builder
+
+	header(&out, "db_queries_total", "counter", "Round trips to the database.")
+	for _, series := range snapshot.Queries {
+		sample(&out, "db_queries_total", queryLabels(series), float64(series.Count))
+	}
+
+	header(&out, "db_query_duration_seconds", "histogram", "Duration of round trips to the database.")
+	for _, series := range snapshot.Queries {
+		labels := queryLabels(series)
+		for i, bound := range series.Duration.Buckets {
+			sample(&out, "db_query_duration_seconds_bucket", append(labels, "le", formatFloat(bound)), float64(series.Duration.Counts[i]))
+		}
+		sample(&out, "db_query_duration_seconds_bucket", append(labels, "le", "+Inf"), float64(series.Duration.Count))
+		sample(&out, "db_query_duration_seconds_sum", labels, series.Duration.Sum)
+		sample(&out, "db_query_duration_seconds_count", labels, float64(series.Duration.Count))
+	}
+
+	header(&out, "db_query_errors_total", "counter", "Failed round trips by error type.")
+	for _, series := range snapshot.Errors {
+		labels := []string{"approach", series.Approach, "operation", series.Operation, "type", series.Type}
+		sample(&out, "db_query_errors_total", labels, float64(series.Count))
+	}
+
+	names := make([]string, 0, len(snapshot.Pools))
+	for name := range snapshot.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pools := []struct {
+		name, kind, help string
+		value            func(PoolStats) float64
+	}{
+		{"db_pool_max_open_connections", "gauge", "Maximum number of open connections.", func(s PoolStats) float64 { return float64(s.MaxOpen) }},
+		{"db_pool_open_connections", "gauge", "Established connections, in use or idle.", func(s PoolStats) float64 { return float64(s.Open) }},
+		{"db_pool_in_use_connections", "gauge", "Connections in use.", func(s PoolStats) float64 { return float64(s.InUse) }},
+		{"db_pool_idle_connections", "gauge", "Idle connections.", func(s PoolStats) float64 { return float64(s.Idle) }},
+		{"db_pool_wait_count_total", "counter", "Connections waited for.", func(s PoolStats) float64 { return float64(s.WaitCount) }},
+		{"db_pool_wait_duration_seconds_total", "counter", "Time spent waiting for connections.", func(s PoolStats) float64 { return s.WaitDuration.Seconds() }},
+	}
+	for _, metric := range pools {
+		header(&out, metric.name, metric.kind, metric.help)
+		for _, name := range names {
+			sample(&out, metric.name, []string{"pool", name}, metric.value(snapshot.Pools[name]))
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func queryLabels(series QuerySeries) []string {
+	return []string{"approach", series.Approach, "operation", series.Operation, "op", series.Op}
+}
+
+func header(out *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one line; labels are name/value pairs.
+func sample(out *strings.Builder, name string, labels []string, value float64) {
+	out.WriteString(name)
+	if len(labels) > 0 {
+		out.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			fmt.Fprintf(out, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		out.WriteByte('}')
+	}
+	out.WriteByte(' ')
+	out.WriteString(formatFloat(value))
+	out.WriteByte('\n')
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,165 @@
+// Package metrics aggregates the statements reported by querylog into
+// counters and duration histograms per approach and operation, and samples
+// the pool statistics of registered databases. Collected figures are served
+// in the Prometheus text format or dumped as JSON.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"m/utils/querylog"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the duration histograms.
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// Scope labels the statements that follow, typically set by the benchmark
+// harness before each operation is measured.
+type Scope struct {
+	Approach  string `json:"approach"`
+	Operation string `json:"operation"`
+}
+
+type queryKey struct {
+	Scope
+	Op string
+}
+
+type errorKey struct {
+	Scope
+	Type string
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	Buckets []float64 `json:"buckets"`
+	Counts  []uint64  `json:"counts"` // cumulative, one per bucket
+	Count   uint64    `json:"count"`
+	Sum     float64   `json:"sum"`
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))}
+}
+
+func (h *Histogram) observe(value float64) {
+	for i, bound := range h.Buckets {
+		if value <= bound {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += value
+}
+
+// Collector is a querylog.Logger that keeps the metrics. It is safe for
+// concurrent use.
+type Collector struct {
+	mu        sync.Mutex
+	buckets   []float64
+	scope     Scope
+	queries   map[queryKey]uint64
+	durations map[queryKey]*Histogram
+	errors    map[errorKey]uint64
+	pools     map[string]*sql.DB
+}
+
+// NewCollector creates a collector; nil buckets selects DefaultBuckets.
+func NewCollector(buckets []float64) *Collector {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return &Collector{
+		buckets:   buckets,
+		queries:   make(map[queryKey]uint64),
+		durations: make(map[queryKey]*Histogram),
+		errors:    make(map[errorKey]uint64),
+		pools:     make(map[string]*sql.DB),
+	}
+}
+
+// SetScope labels the statements reported from now on. There is one scope per
+// collector, not per context: statements that overlap a scope change, such as
+// those of concurrent benchmarks, may be counted under the other scope.
+func (c *Collector) SetScope(scope Scope) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.scope = scope
+}
+
+// RegisterPool samples the statistics of db under name when metrics are
+// written, replacing any pool registered under the same name.
+func (c *Collector) RegisterPool(name string, db *sql.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pools[name] = db
+}
+
+func (c *Collector) LogQuery(_ context.Context, entry querylog.Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := queryKey{Scope: c.scope, Op: entry.Op}
+	c.queries[key]++
+	histogram, exists := c.durations[key]
+	if !exists {
+		histogram = newHistogram(c.buckets)
+		c.durations[key] = histogram
+	}
+	histogram.observe(entry.Duration.Seconds())
+
+	if entry.Err != nil {
+		c.errors[errorKey{Scope: c.scope, Type: ErrorType(entry.Err)}]++
+	}
+}
+
+func (c *Collector) IgnoresCaller() bool {
+	return true
+}
+
+// ErrorType classifies an error for the errors metric: the condition name of
+// a PostgreSQL error from lib/pq or pgx, or a generic category.
+func ErrorType(err error) string {
+	var pgErr interface{ SQLState() string }
+	switch {
+	case errors.As(err, &pgErr):
+		return pq.ErrorCode(pgErr.SQLState()).Name()
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, driver.ErrBadConn):
+		return "bad_connection"
+	}
+	return "other"
+}
+
+// PoolStats are the figures of sql.DBStats exposed for each pool.
+type PoolStats struct {
+	MaxOpen      int           `json:"maxOpen"`
+	Open         int           `json:"open"`
+	InUse        int           `json:"inUse"`
+	Idle         int           `json:"idle"`
+	WaitCount    int64         `json:"waitCount"`
+	WaitDuration time.Duration `json:"waitDuration"`
+}
+
+func poolStats(db *sql.DB) PoolStats {
+	stats := db.Stats()
+	return PoolStats{
+		MaxOpen:      stats.MaxOpenConnections,
+		Open:         stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration,
+	}
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"m/utils/querylog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&pq.Error{Code: "23505"}, "unique_violation"},
+		{fmt.Errorf("insert: %w", &pq.Error{Code: "23503"}), "foreign_key_violation"},
+		{context.DeadlineExceeded, "timeout"},
+		{sql.ErrNoRows, "other"},
+	}
+	for _, test := range tests {
+		if got := ErrorType(test.err); got != test.expected {
+			t.Errorf("ErrorType(%v) = %q, expected %q", test.err, got, test.expected)
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector([]float64{0.001, 0.01})
+	ctx := context.Background()
+
+	collector.SetScope(Scope{Approach: "GORM", Operation: "ReadProject"})
+	collector.LogQuery(ctx, querylog.Entry{Op: "query", Duration: 500 * time.Microsecond})
+	collector.LogQuery(ctx, querylog.Entry{Op: "query", Duration: 5 * time.Millisecond})
+	collector.SetScope(Scope{Approach: "GORM", Operation: "InsertProject"})
+	collector.LogQuery(ctx, querylog.Entry{Op: "exec", Duration: 20 * time.Millisecond, Err: &pq.Error{Code: "23505"}})
+
+	snapshot := collector.Snapshot()
+	if len(snapshot.Queries) != 2 || len(snapshot.Errors) != 1 {
+		t.Fatalf("Unexpected snapshot: %+v", snapshot)
+	}
+	read := snapshot.Queries[1]
+	if read.Operation != "ReadProject" || read.Count != 2 || read.Duration.Counts[0] != 1 || read.Duration.Counts[1] != 2 {
+		t.Errorf("Unexpected ReadProject series: %+v", read)
+	}
+	if snapshot.Errors[0].Operation != "InsertProject" || snapshot.Errors[0].Type != "unique_violation" {
+		t.Errorf("Unexpected error series: %+v", snapshot.Errors[0])
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	collector := NewCollector([]float64{0.01})
+	collector.SetScope(Scope{Approach: "DirectStruct", Operation: `Read"Project`})
+	collector.LogQuery(context.Background(), querylog.Entry{Op: "query", Duration: 2 * time.Millisecond})
+
+	db, err := sql.Open("postgres", "host=localhost")
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(4)
+	collector.RegisterPool("DirectStruct/postgres", db)
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	labels := `approach="DirectStruct",operation="Read\"Project",op="query"`
+	for _, line := range []string{
+		"# TYPE db_queries_total counter",
+		"db_queries_total{" + labels + "} 1",
+		"# TYPE db_query_duration_seconds histogram",
+		"db_query_duration_seconds_bucket{" + labels + `,le="0.01"} 1`,
+		"db_query_duration_seconds_bucket{" + labels + `,le="+Inf"} 1`,
+		"db_query_duration_seconds_sum{" + labels + "} 0.002",
+		`db_pool_max_open_connections{pool="DirectStruct/postgres"} 4`,
+		`db_pool_wait_count_total{pool="DirectStruct/postgres"} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, body)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := collector.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(buf.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if snapshot.Pools["DirectStruct/postgres"].MaxOpen != 4 || len(snapshot.Queries) != 1 {
+		t.Errorf("Unexpected JSON snapshot: %s", buf.String())
+	}
+}
+
+func TestIgnoresCaller(t *testing.T) {
+	if !NewCollector(nil).IgnoresCaller() {
+		t.Error("Expected the collector to skip the caller lookup")
+	}
+}
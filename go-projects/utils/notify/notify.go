@@ -0,0 +1,142 @@
+// Package notify shares row changes between processes over PostgreSQL
+// LISTEN/NOTIFY and evicts the matching entries of a local cache.
+//
+// Changes are published either by the triggers in database/notify.sql, which
+// cover every write including cascades, or explicitly with Publish.
+package notify
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"m/utils/cache"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel is the channel used by the triggers in database/notify.sql.
+const Channel = "row_changes"
+
+// pingInterval keeps an idle listener connection checked, as advised by lib/pq.
+const pingInterval = 90 * time.Second
+
+// Event is the payload of a change notification.
+type Event struct {
+	Table string `json:"table"`
+	ID    int    `json:"id"`
+	Op    string `json:"op"`
+}
+
+// Key returns the cache key of the changed row.
+func (e Event) Key() cache.Key {
+	return cache.NewKey(e.Table, e.ID)
+}
+
+func Parse(payload string) (Event, error) {
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return event, fmt.Errorf("notify: malformed payload %q: %w", payload, err)
+	}
+	if event.Table == "" {
+		return event, fmt.Errorf("notify: payload %q has no table", payload)
+	}
+	return event, nil
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Publish sends events on channel. Inside a transaction they are delivered
+// when it commits, and dropped if it rolls back.
+func Publish(db execer, channel string, events ...Event) error {
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec("SELECT pg_notify($1, $2)", channel, string(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscriber listens on a channel and invalidates the cache entries read from
+// each changed row. Notifications sent while it was disconnected are lost, so
+// the whole cache is purged when the connection is re-established.
+type Subscriber struct {
+	listener *pq.Listener
+	cache    *cache.Cache
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Subscribe connects to the database at connStr and starts evicting entries of
+// c for every event received on channel.
+func Subscribe(connStr string, c *cache.Cache, channel string) (*Subscriber, error) {
+	// Listen blocks until a connection is made, so fail fast when there is none.
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	db.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Subscriber{cache: c, done: make(chan struct{})}
+	s.listener = pq.NewListener(connStr, 10*time.Millisecond, time.Minute, s.connectionEvent)
+	if err := s.listener.Listen(channel); err != nil {
+		s.listener.Close()
+		return nil, err
+	}
+
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+func (s *Subscriber) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case notification := <-s.listener.Notify:
+			if notification == nil {
+				s.cache.Purge()
+				continue
+			}
+			event, err := Parse(notification.Extra)
+			if err != nil {
+				log.Print(err)
+				continue
+			}
+			s.cache.Invalidate(event.Key())
+		case <-ticker.C:
+			go s.listener.Ping()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Subscriber) connectionEvent(event pq.ListenerEventType, err error) {
+	if err != nil {
+		log.Printf("notify: listener event %d: %v", event, err)
+	}
+}
+
+// Close stops listening. Cached entries are left as they are.
+func (s *Subscriber) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return s.listener.Close()
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"database/sql"
+	base "m/tests/Base"
+	"m/utils/cache"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func TestParse(t *testing.T) {
+	event, err := Parse(`{"table": "TASKS", "id": 7, "op": "UPDATE"}`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if event.Key() != cache.NewKey("tasks", 7) || event.Op != "UPDATE" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	for _, payload := range []string{"", "tasks:7", `{"id": 7}`} {
+		if _, err := Parse(payload); err == nil {
+			t.Errorf("Expected an error for payload %q", payload)
+		}
+	}
+}
+
+// TestSubscriber needs the test database; it is skipped when it is not running.
+func TestSubscriber(t *testing.T) {
+	c := cache.New(10, 0)
+	subscriber, err := Subscribe(base.PsqlInfo, c, Channel)
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	defer subscriber.Close()
+
+	db := base.SetupDB()
+	defer db.Close()
+
+	c.Set("project/1", "graph", cache.NewKey("projects", 1), cache.NewKey("tasks", 10))
+	c.Set("project/2", "graph", cache.NewKey("projects", 2))
+	if err := Publish(db, Channel, Event{Table: "TASKS", ID: 10, Op: "UPDATE"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	waitForSize(t, c, 1)
+
+	if !hasTriggers(db) {
+		t.Skip("database/notify.sql is not installed")
+	}
+	_, err = db.Exec("INSERT INTO RESOURCES (ID, TYPE, NAME, STATUS) VALUES (-1, 'test', 'notify', 'available')")
+	if err != nil {
+		t.Fatalf("Failed to insert resource: %v", err)
+	}
+	defer db.Exec("DELETE FROM RESOURCES WHERE ID = -1")
+
+	c.Set("resource/-1", "row", cache.NewKey("resources", -1))
+	if _, err := db.Exec("UPDATE RESOURCES SET NAME = 'changed' WHERE ID = -1"); err != nil {
+		t.Fatalf("Failed to update resource: %v", err)
+	}
+	waitForSize(t, c, 1)
+}
+
+func waitForSize(t *testing.T, c *cache.Cache, size int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Stats().Size != size {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d cached entries, got %+v", size, c.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func hasTriggers(db *sql.DB) bool {
+	var installed bool
+	err := db.QueryRow("SELECT to_regproc('notify_row_change') IS NOT NULL").Scan(&installed)
+	return err == nil && installed
+}
@@ -0,0 +1,108 @@
+// Package orderby turns a caller-chosen sort order for a project's tasks and
+// resources into validated ORDER BY clauses.
+package orderby
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrderBy selects how ReadProject sorts a project's tasks and each task's
+// resources. Each field is a comma-separated list of columns, each optionally
+// followed by ASC or DESC. Empty fields sort by primary key, and the primary
+// key is always appended as a tie-breaker so the order is total.
+type OrderBy struct {
+	Tasks     string
+	Resources string
+}
+
+// ByKey sorts tasks and resources by their primary keys.
+var ByKey = OrderBy{}
+
+var (
+	taskColumns     = []string{"ID", "NAME", "RESPONSIBLE", "DEADLINE", "STATUS", "PRIORITY", "ESTIMATED_TIME", "DESCRIPTION"}
+	resourceColumns = []string{"ID", "TYPE", "NAME", "DAILY_COST", "STATUS", "SUPPLIER", "QUANTITY", "ACQUISITION_DATE"}
+)
+
+// Resolve returns the first order given, or ByKey when there is none. It lets
+// ReadProject take the order as an optional trailing argument.
+func Resolve(order []OrderBy) OrderBy {
+	if len(order) == 0 {
+		return ByKey
+	}
+	return order[0]
+}
+
+// TaskClause returns the ORDER BY terms for tasks, qualified by alias when it is not empty.
+func (o OrderBy) TaskClause(alias string) (string, error) {
+	return clause(o.Tasks, alias, taskColumns)
+}
+
+// ResourceClause returns the ORDER BY terms for resources, qualified by alias when it is not empty.
+func (o OrderBy) ResourceClause(alias string) (string, error) {
+	return clause(o.Resources, alias, resourceColumns)
+}
+
+// JoinClause returns the ORDER BY terms for the rows of a join of tasks with
+// their resources: tasks first, then the resources of each task, so that the
+// rows of one task are adjacent and in order.
+func (o OrderBy) JoinClause(taskAlias, resourceAlias string) (string, error) {
+	tasks, err := o.TaskClause(taskAlias)
+	if err != nil {
+		return "", err
+	}
+	resources, err := o.ResourceClause(resourceAlias)
+	if err != nil {
+		return "", err
+	}
+	return tasks + ", " + resources, nil
+}
+
+func clause(spec, alias string, allowed []string) (string, error) {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+
+	var terms []string
+	hasKey := false
+	for _, part := range strings.Split(spec, ",") {
+		words := strings.Fields(part)
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) > 2 {
+			return "", fmt.Errorf("invalid order term %q", strings.TrimSpace(part))
+		}
+
+		column := strings.ToUpper(words[0])
+		if !contains(allowed, column) {
+			return "", fmt.Errorf("unknown order column %q", words[0])
+		}
+		hasKey = hasKey || column == "ID"
+
+		term := prefix + column
+		if len(words) == 2 {
+			direction := strings.ToUpper(words[1])
+			if direction != "ASC" && direction != "DESC" {
+				return "", fmt.Errorf("invalid order direction %q", words[1])
+			}
+			term += " " + direction
+		}
+		terms = append(terms, term)
+	}
+
+	if !hasKey {
+		terms = append(terms, prefix+"ID")
+	}
+	return strings.Join(terms, ", "), nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
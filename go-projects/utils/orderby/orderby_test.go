@@ -0,0 +1,44 @@
+package orderby
+
+import "testing"
+
+func TestClauses(t *testing.T) {
+	cases := []struct {
+		order     OrderBy
+		tasks     string
+		resources string
+	}{
+		{ByKey, "t.ID", "r.ID"},
+		{OrderBy{Tasks: "deadline desc", Resources: "daily_cost, name"}, "t.DEADLINE DESC, t.ID", "r.DAILY_COST, r.NAME, r.ID"},
+		{OrderBy{Tasks: "id DESC"}, "t.ID DESC", "r.ID"},
+	}
+
+	for _, c := range cases {
+		tasks, err := c.order.TaskClause("t")
+		if err != nil || tasks != c.tasks {
+			t.Errorf("TaskClause(%q) = %q, %v; want %q", c.order.Tasks, tasks, err, c.tasks)
+		}
+		resources, err := c.order.ResourceClause("r")
+		if err != nil || resources != c.resources {
+			t.Errorf("ResourceClause(%q) = %q, %v; want %q", c.order.Resources, resources, err, c.resources)
+		}
+	}
+}
+
+func TestJoinClause(t *testing.T) {
+	order := OrderBy{Tasks: "priority desc", Resources: "name"}
+	if clause, err := order.JoinClause("t", "r"); err != nil || clause != "t.PRIORITY DESC, t.ID, r.NAME, r.ID" {
+		t.Errorf("JoinClause = %q, %v", clause, err)
+	}
+	if _, err := (OrderBy{Resources: "budget"}).JoinClause("t", "r"); err == nil {
+		t.Errorf("Expected an unknown resource column to be rejected")
+	}
+}
+
+func TestClauseRejectsUnknownTerms(t *testing.T) {
+	for _, spec := range []string{"id; DROP TABLE TASKS", "name sideways", "budget", "name desc nulls"} {
+		if _, err := (OrderBy{Tasks: spec}).TaskClause(""); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
@@ -1,50 +1,240 @@
 package utils
 
 import (
+	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
-	"log"
 	"reflect"
-	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
-func PrintQuery(query string, args ...interface{}) {
-	callerFuncName, line := callerFunctionName()
-	info := formatQuery(query, args...)
-	log.Printf("[DB LOG]\n\tFunc: %s\n\tLine:%d\n\tQuery: %s\n", callerFuncName, line, info)
+// Redacted replaces the value of sensitive arguments in formatted queries.
+const Redacted = "<redacted>"
+
+// Sensitive marks a query argument that must not appear in logs. The driver
+// receives Arg unchanged.
+type Sensitive struct {
+	Arg interface{}
+}
+
+func (s Sensitive) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(s.Arg)
+}
+
+// String, GoString and MarshalJSON keep the value out of fmt, log and JSON output.
+func (s Sensitive) String() string               { return Redacted }
+func (s Sensitive) GoString() string             { return Redacted }
+func (s Sensitive) MarshalJSON() ([]byte, error) { return []byte(`"` + Redacted + `"`), nil }
+
+// FormatQuery substitutes the $n placeholders of query with args, for logging.
+// Placeholders inside string literals, quoted identifiers, dollar-quoted
+// strings and comments are left alone. Sensitive arguments are redacted.
+func FormatQuery(query string, args ...interface{}) string {
+	return FormatQueryRedacted(query, nil, args...)
+}
+
+// FormatQueryRedacted is FormatQuery that also redacts the arguments for which
+// redact returns true. Positions start at 1, like placeholders.
+func FormatQueryRedacted(query string, redact func(position int, arg interface{}) bool, args ...interface{}) string {
+	var out strings.Builder
+	for i := 0; i < len(query); {
+		end := tokenEnd(query, i)
+		token := query[i:end]
+		i = end
+
+		position, isPlaceholder := placeholder(token)
+		if !isPlaceholder || position > len(args) {
+			out.WriteString(token)
+			continue
+		}
+		arg := args[position-1]
+		if _, sensitive := arg.(Sensitive); sensitive || (redact != nil && redact(position, arg)) {
+			out.WriteString(quote(Redacted))
+			continue
+		}
+		out.WriteString(formatArg(arg))
+	}
+	return out.String()
+}
+
+// tokenEnd returns the end of the token starting at query[start]. Literals,
+// identifiers, comments and placeholders are whole tokens; anything else is a
+// single byte.
+func tokenEnd(query string, start int) int {
+	rest := query[start:]
+	switch {
+	case strings.HasPrefix(rest, "--"):
+		if newline := strings.IndexByte(rest, '\n'); newline >= 0 {
+			return start + newline
+		}
+		return len(query)
+	case strings.HasPrefix(rest, "/*"):
+		return blockCommentEnd(query, start)
+	case rest[0] == '\'':
+		escapes := start > 0 && (query[start-1] == 'E' || query[start-1] == 'e') && (start < 2 || !isIdentByte(query[start-2]))
+		return quotedEnd(query, start, '\'', escapes)
+	case rest[0] == '"':
+		return quotedEnd(query, start, '"', false)
+	case rest[0] == '$':
+		if len(rest) > 1 && isDigit(rest[1]) {
+			end := start + 1
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+			return end
+		}
+		if tag, ok := dollarTag(rest); ok {
+			if closing := strings.Index(rest[len(tag):], tag); closing >= 0 {
+				return start + len(tag) + closing + len(tag)
+			}
+			return len(query)
+		}
+		return start + 1
+	case isIdentByte(rest[0]):
+		// Identifiers may contain $, which must not start a placeholder.
+		end := start
+		for end < len(query) && (isIdentByte(query[end]) || query[end] == '$') {
+			end++
+		}
+		return end
+	}
+	return start + 1
+}
+
+// quotedEnd finds the closing quote of a literal or identifier, where a doubled
+// quote is part of the text.
+func quotedEnd(query string, start int, quote byte, backslashEscapes bool) int {
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case backslashEscapes && query[i] == '\\':
+			i++
+		case query[i] == quote && i+1 < len(query) && query[i+1] == quote:
+			i++
+		case query[i] == quote:
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// blockCommentEnd finds the end of a comment; PostgreSQL comments nest.
+func blockCommentEnd(query string, start int) int {
+	depth := 0
+	for i := start; i+1 < len(query); i++ {
+		switch query[i : i+2] {
+		case "/*":
+			depth++
+			i++
+		case "*/":
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(query)
 }
 
-func callerFunctionName() (string, int) {
-	pc, _, line, _ := runtime.Caller(2)
-	return runtime.FuncForPC(pc).Name(), line
+// dollarTag returns the opening tag of a dollar-quoted string, such as $$ or $body$.
+func dollarTag(rest string) (string, bool) {
+	for i := 1; i < len(rest); i++ {
+		switch {
+		case rest[i] == '$':
+			return rest[:i+1], true
+		case !isIdentByte(rest[i]):
+			return "", false
+		}
+	}
+	return "", false
 }
 
-func formatQuery(query string, args ...interface{}) string {
-	for i, arg := range args {
-		placeholder := "$" + strconv.Itoa(i+1)
-		argStr := formatArg(arg)
-		query = strings.Replace(query, placeholder, argStr, 1)
+func placeholder(token string) (int, bool) {
+	if len(token) < 2 || token[0] != '$' || !isDigit(token[1]) {
+		return 0, false
 	}
-	return query
+	position, err := strconv.Atoi(token[1:])
+	return position, err == nil && position > 0
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || isDigit(b) || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b >= 0x80
 }
 
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// formatArg renders an argument as a PostgreSQL literal.
 func formatArg(arg interface{}) string {
-	// Desreferenciar ponteiros
 	value := reflect.ValueOf(arg)
-	if value.Kind() == reflect.Ptr {
-		if value.IsNil() {
-			return "NULL"
+	if arg == nil || (value.Kind() == reflect.Ptr && value.IsNil()) {
+		return "NULL"
+	}
+	switch arg.(type) {
+	case Sensitive, *Sensitive:
+		return quote(Redacted)
+	}
+	// Valuers render as the driver would send them, such as pq.Array.
+	if valuer, ok := arg.(driver.Valuer); ok {
+		converted, err := valuer.Value()
+		if err != nil {
+			return quote(fmt.Sprintf("<invalid: %v>", err))
+		}
+		if _, again := converted.(driver.Valuer); again {
+			return quote(fmt.Sprint(converted))
 		}
+		return formatArg(converted)
+	}
+
+	// Desreferenciar ponteiros
+	if value.Kind() == reflect.Ptr {
 		arg = value.Elem().Interface()
 	}
 
 	switch v := arg.(type) {
 	case string:
-		return fmt.Sprintf("'%s'", v)
+		return quote(v)
+	case []byte:
+		return `'\x` + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return quote(v.Format("2006-01-02 15:04:05.999999999Z07:00"))
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
 	case fmt.Stringer:
-		return fmt.Sprintf("'%s'", v.String())
-	default:
-		return fmt.Sprintf("%v", v)
+		return quote(v.String())
+	}
+
+	value = reflect.ValueOf(arg)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", arg)
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return "NULL"
+		}
+		if value.Len() == 0 {
+			return "'{}'"
+		}
+		elements := make([]string, value.Len())
+		for i := range elements {
+			elements[i] = formatArg(value.Index(i).Interface())
+		}
+		return "ARRAY[" + strings.Join(elements, ", ") + "]"
 	}
+	return quote(fmt.Sprintf("%v", arg))
 }
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestFormatQuery(t *testing.T) {
+	args := make([]interface{}, 11)
+	for i := range args {
+		args[i] = i + 1
+	}
+	name := "O'Brien"
+	var missing *string
+
+	tests := []struct {
+		name  string
+		query string
+		args  []interface{}
+		want  string
+	}{
+		{"two-digit placeholders", "SELECT $1, $10, $11", args, "SELECT 1, 10, 11"},
+		{"unknown placeholders kept", "SELECT $1, $3", []interface{}{1}, "SELECT 1, $3"},
+		{"quotes escaped", "UPDATE T SET NAME = $1, BOSS = $2", []interface{}{name, &name}, "UPDATE T SET NAME = 'O''Brien', BOSS = 'O''Brien'"},
+		{"null", "UPDATE T SET A = $1, B = $2, C = $3", []interface{}{nil, missing, sql.NullString{}}, "UPDATE T SET A = NULL, B = NULL, C = NULL"},
+		{"literals untouched", `SELECT '$1', 'it''s $1', E'\'$1', "col$1", $1`, []interface{}{7}, `SELECT '$1', 'it''s $1', E'\'$1', "col$1", 7`},
+		{"dollar quotes untouched", "SELECT $$ $1 $$, $body$ $1 $body$, $1", []interface{}{7}, "SELECT $$ $1 $$, $body$ $1 $body$, 7"},
+		{"comments untouched", "SELECT $1 -- $1\n/* $1 /* $1 */ $1 */ + $1", []interface{}{7}, "SELECT 7 -- $1\n/* $1 /* $1 */ $1 */ + 7"},
+		{"identifier with dollar", "SELECT a$1 FROM T WHERE ID = $1", []interface{}{7}, "SELECT a$1 FROM T WHERE ID = 7"},
+		{"bytes", "SELECT $1", []interface{}{[]byte{0xde, 0xad}}, `SELECT '\xdead'`},
+		{"time", "SELECT $1", []interface{}{time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)}, "SELECT '2024-05-01 12:30:00Z'"},
+		{"bool and float", "SELECT $1, $2", []interface{}{true, 3.14}, "SELECT TRUE, 3.14"},
+		{"slices", "SELECT $1, $2, $3", []interface{}{[]string{"a", "b'"}, []int{}, pq.Array([]int64{1, 2})}, "SELECT ARRAY['a', 'b'''], '{}', '{1,2}'"},
+		{"sensitive", "UPDATE USERS SET PASSWORD = $1", []interface{}{Sensitive{Arg: "hunter2"}}, "UPDATE USERS SET PASSWORD = '<redacted>'"},
+	}
+	for _, test := range tests {
+		if got := FormatQuery(test.query, test.args...); got != test.want {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, got)
+		}
+	}
+}
+
+func TestFormatQueryRedacted(t *testing.T) {
+	redactSecond := func(position int, _ interface{}) bool { return position == 2 }
+	got := FormatQueryRedacted("INSERT INTO USERS VALUES ($1, $2)", redactSecond, "alice", "hunter2")
+	if want := "INSERT INTO USERS VALUES ('alice', '<redacted>')"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,57 @@
+package querylog
+
+import (
+	"context"
+	"sync"
+)
+
+// Counter counts round trips, in total and by Entry.Op. It is safe for
+// concurrent use and cheap enough to leave enabled in benchmarks.
+type Counter struct {
+	mu    sync.Mutex
+	total int64
+	byOp  map[string]int64
+}
+
+func (c *Counter) LogQuery(_ context.Context, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.byOp == nil {
+		c.byOp = make(map[string]int64)
+	}
+	c.total++
+	c.byOp[entry.Op]++
+}
+
+func (c *Counter) IgnoresCaller() bool {
+	return true
+}
+
+// Count returns the number of round trips so far.
+func (c *Counter) Count() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.total
+}
+
+// ByOp returns a copy of the counts by operation, such as "query" or "exec".
+func (c *Counter) ByOp() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int64, len(c.byOp))
+	for op, count := range c.byOp {
+		counts[op] = count
+	}
+	return counts
+}
+
+// Measure runs op and returns the round trips counted while it ran, including
+// those of concurrent callers sharing the counter.
+func (c *Counter) Measure(op func() error) (int64, error) {
+	start := c.Count()
+	err := op()
+	return c.Count() - start, err
+}
@@ -0,0 +1,380 @@
+package querylog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"m/utils"
+	"reflect"
+	"time"
+)
+
+// Open opens a database like sql.Open and reports its statements to logger.
+// driverName must already be registered, as by importing github.com/lib/pq.
+func Open(driverName, dsn string, logger Logger) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+
+	var base driver.Connector = dsnConnector{dsn: dsn, driver: drv}
+	if withContext, ok := drv.(driver.DriverContext); ok {
+		if base, err = withContext.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(Wrap(base, logger)), nil
+}
+
+// Wrap returns a connector whose connections report their statements to logger.
+func Wrap(base driver.Connector, logger Logger) driver.Connector {
+	return &connector{base: base, logger: logger}
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+type connector struct {
+	base   driver.Connector
+	logger Logger
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, logger: c.logger}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// report reports a finished round trip started at start.
+func report(ctx context.Context, logger Logger, op, query string, args []interface{}, start time.Time, rows int64, err error) {
+	entry := Entry{Op: op, Query: query, Args: args, Duration: time.Since(start), Rows: rows, Err: err}
+	if !ignoresCaller(logger) {
+		entry.Caller = caller()
+	}
+	logger.LogQuery(ctx, entry)
+}
+
+func rowsAffected(result driver.Result) int64 {
+	if result == nil {
+		return -1
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return affected
+}
+
+// conn forwards the optional driver interfaces to the wrapped connection, so
+// database/sql behaves as it would with the driver alone.
+type conn struct {
+	driver.Conn
+	logger Logger
+	// sensitive holds the ordinals of the utils.Sensitive arguments of the
+	// statement being converted, which database/sql unwraps before the driver.
+	sensitive []int
+}
+
+// loggedArgs returns the arguments to log, wrapping the sensitive ones again.
+func (c *conn) loggedArgs(args []driver.NamedValue) []interface{} {
+	defer func() { c.sensitive = c.sensitive[:0] }()
+	if len(args) == 0 {
+		return nil
+	}
+	logged := make([]interface{}, len(args))
+	for i, arg := range args {
+		logged[i] = arg.Value
+	}
+	for _, ordinal := range c.sensitive {
+		if ordinal >= 1 && ordinal <= len(logged) {
+			logged[ordinal-1] = utils.Sensitive{Arg: logged[ordinal-1]}
+		}
+	}
+	return logged
+}
+
+// checkNamedValue unwraps utils.Sensitive arguments, remembering their
+// ordinals, and converts them as checker or database/sql would.
+func (c *conn) checkNamedValue(checker interface{}, value *driver.NamedValue) error {
+	if value.Ordinal == 1 {
+		c.sensitive = c.sensitive[:0]
+	}
+	secret, sensitive := value.Value.(utils.Sensitive)
+	if sensitive {
+		c.sensitive = append(c.sensitive, value.Ordinal)
+		value.Value = secret.Arg
+	}
+
+	if checker, ok := checker.(driver.NamedValueChecker); ok {
+		if err := checker.CheckNamedValue(value); err != driver.ErrSkip || !sensitive {
+			return err
+		}
+	}
+	if !sensitive {
+		return driver.ErrSkip
+	}
+	converted, err := driver.DefaultParameterConverter.ConvertValue(value.Value)
+	value.Value = converted
+	return err
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var s driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = preparer.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	report(ctx, c.logger, "prepare", query, nil, start, -1, err)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, conn: c, query: query}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	report(ctx, c.logger, "exec", query, c.loggedArgs(args), start, rowsAffected(result), err)
+	return result, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := queryer.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	logged := c.loggedArgs(args)
+	if err != nil {
+		report(ctx, c.logger, "query", query, logged, start, -1, err)
+		return nil, err
+	}
+	return &rows{Rows: result, ctx: ctx, logger: c.logger, query: query, args: logged, start: start}, nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var t driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		t, err = beginner.BeginTx(ctx, opts)
+	} else {
+		t, err = c.Conn.Begin()
+	}
+	report(ctx, c.logger, "begin", "", nil, start, -1, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, ctx: ctx, logger: c.logger}, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(value *driver.NamedValue) error {
+	return c.checkNamedValue(c.Conn, value)
+}
+
+type tx struct {
+	driver.Tx
+	ctx    context.Context
+	logger Logger
+}
+
+func (t *tx) Commit() error {
+	start := time.Now()
+	err := t.Tx.Commit()
+	report(t.ctx, t.logger, "commit", "", nil, start, -1, err)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	start := time.Now()
+	err := t.Tx.Rollback()
+	report(t.ctx, t.logger, "rollback", "", nil, start, -1, err)
+	return err
+}
+
+type stmt struct {
+	driver.Stmt
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(values(args))
+	}
+	report(ctx, s.conn.logger, "exec", s.query, s.conn.loggedArgs(args), start, rowsAffected(result), err)
+	return result, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var result driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		result, err = queryer.QueryContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Query(values(args))
+	}
+	logged := s.conn.loggedArgs(args)
+	if err != nil {
+		report(ctx, s.conn.logger, "query", s.query, logged, start, -1, err)
+		return nil, err
+	}
+	return &rows{Rows: result, ctx: ctx, logger: s.conn.logger, query: s.query, args: logged, start: start}, nil
+}
+
+func (s *stmt) CheckNamedValue(value *driver.NamedValue) error {
+	if _, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return s.conn.checkNamedValue(s.Stmt, value)
+	}
+	return s.conn.checkNamedValue(s.conn.Conn, value)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	plain := make([]driver.Value, len(args))
+	for i, arg := range args {
+		plain[i] = arg.Value
+	}
+	return plain
+}
+
+// rows reports its query when closed, with the number of rows read.
+type rows struct {
+	driver.Rows
+	ctx    context.Context
+	logger Logger
+	query  string
+	args   []interface{}
+	start  time.Time
+	count  int64
+	err    error
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.count++
+	} else if err != io.EOF {
+		r.err = err
+	}
+	return err
+}
+
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	report(r.ctx, r.logger, "query", r.query, r.args, r.start, r.count, r.err)
+	return err
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return typed.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typed.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *rows) ColumnTypeLength(index int) (int64, bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return typed.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *rows) ColumnTypeNullable(index int) (bool, bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return typed.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return typed.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,72 @@
+package querylog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// gormLogger adapts a Logger to GORM. GORM interpolates the arguments into the
+// statement itself, so entries carry no Args.
+type gormLogger struct {
+	logger Logger
+	level  gormlogger.LogLevel
+}
+
+// Gorm returns a GORM logger that reports every statement to logger. Set it
+// as gorm.Config.Logger. GORM's own messages go to slog.Default.
+func Gorm(logger Logger) gormlogger.Interface {
+	return &gormLogger{logger: logger, level: gormlogger.Info}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *gormLogger) Info(ctx context.Context, message string, args ...interface{}) {
+	l.message(ctx, gormlogger.Info, slog.LevelInfo, message, args)
+}
+
+func (l *gormLogger) Warn(ctx context.Context, message string, args ...interface{}) {
+	l.message(ctx, gormlogger.Warn, slog.LevelWarn, message, args)
+}
+
+func (l *gormLogger) Error(ctx context.Context, message string, args ...interface{}) {
+	l.message(ctx, gormlogger.Error, slog.LevelError, message, args)
+}
+
+func (l *gormLogger) message(ctx context.Context, level gormlogger.LogLevel, slogLevel slog.Level, message string, args []interface{}) {
+	if l.level >= level {
+		slog.Default().Log(ctx, slogLevel, fmt.Sprintf(message, args...))
+	}
+}
+
+// Trace reports a statement unless the logger is silent. A missing record is
+// not reported as an error, as GORM returns it for empty First and Take.
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	query, rows := fc()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	op := "exec"
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SELECT") {
+		op = "query"
+	}
+	entry := Entry{Op: op, Query: query, Duration: time.Since(begin), Rows: rows, Err: err}
+	if !ignoresCaller(l.logger) {
+		entry.Caller = caller()
+	}
+	l.logger.LogQuery(ctx, entry)
+}
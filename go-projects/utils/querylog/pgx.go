@@ -0,0 +1,113 @@
+package querylog
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PgxTracer adapts a Logger to pgx. A batch is a single round trip, so it is
+// reported as one entry with the batch op, whose Query joins the queued
+// statements and whose Rows adds up their command tags. CopyFrom is reported
+// with the copy op.
+type PgxTracer struct {
+	logger Logger
+}
+
+// Pgx returns a tracer that reports every round trip of pgx to logger. Set it
+// as pgx.ConnConfig.Tracer.
+func Pgx(logger Logger) *PgxTracer {
+	return &PgxTracer{logger: logger}
+}
+
+type pgxTraceKey struct{}
+
+// pgxTrace is the round trip in progress, carried by the context between the
+// start and end hooks.
+type pgxTrace struct {
+	start   time.Time
+	query   string
+	args    []interface{}
+	queries []string
+	rows    int64
+	err     error
+}
+
+func (t *PgxTracer) begin(ctx context.Context, trace *pgxTrace) context.Context {
+	trace.start = time.Now()
+	return context.WithValue(ctx, pgxTraceKey{}, trace)
+}
+
+func (t *PgxTracer) end(ctx context.Context, op string, rows int64, err error) {
+	trace, ok := ctx.Value(pgxTraceKey{}).(*pgxTrace)
+	if !ok {
+		return
+	}
+	report(ctx, t.logger, op, trace.query, trace.args, trace.start, rows, err)
+}
+
+func (t *PgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return t.begin(ctx, &pgxTrace{query: data.SQL, args: data.Args})
+}
+
+// TraceQueryEnd runs when Exec returns or the rows of Query are closed.
+func (t *PgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	op := "exec"
+	if data.CommandTag.Select() {
+		op = "query"
+	}
+	t.end(ctx, op, data.CommandTag.RowsAffected(), data.Err)
+}
+
+func (t *PgxTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return t.begin(ctx, &pgxTrace{})
+}
+
+func (t *PgxTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	trace, ok := ctx.Value(pgxTraceKey{}).(*pgxTrace)
+	if !ok {
+		return
+	}
+	trace.queries = append(trace.queries, strings.TrimSpace(data.SQL))
+	trace.rows += data.CommandTag.RowsAffected()
+	if trace.err == nil {
+		trace.err = data.Err
+	}
+}
+
+func (t *PgxTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	trace, ok := ctx.Value(pgxTraceKey{}).(*pgxTrace)
+	if !ok {
+		return
+	}
+	trace.query = strings.Join(trace.queries, ";\n")
+	err := data.Err
+	if err == nil {
+		err = trace.err
+	}
+	t.end(ctx, "batch", trace.rows, err)
+}
+
+func (t *PgxTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	query := "COPY " + data.TableName.Sanitize() + " (" + strings.Join(data.ColumnNames, ", ") + ") FROM STDIN"
+	return t.begin(ctx, &pgxTrace{query: query})
+}
+
+func (t *PgxTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.end(ctx, "copy", data.CommandTag.RowsAffected(), data.Err)
+}
+
+// TracePrepareStart covers the statements that pgx prepares and caches on
+// first use, each a round trip of its own.
+func (t *PgxTracer) TracePrepareStart(ctx context.Context, _ *pgx.Conn, data pgx.TracePrepareStartData) context.Context {
+	return t.begin(ctx, &pgxTrace{query: data.SQL})
+}
+
+func (t *PgxTracer) TracePrepareEnd(ctx context.Context, _ *pgx.Conn, data pgx.TracePrepareEndData) {
+	if data.AlreadyPrepared {
+		return
+	}
+	t.end(ctx, "prepare", -1, data.Err)
+}
@@ -0,0 +1,110 @@
+// Package querylog reports every statement sent to the database to a Logger,
+// through a database/sql driver wrapper (Open, Wrap), a GORM logger (Gorm) or
+// a pgx tracer (Pgx).
+package querylog
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry describes one round trip to the database.
+type Entry struct {
+	Op       string        // prepare, exec, query, begin, commit or rollback
+	Query    string        // statement text, empty for begin, commit and rollback
+	Args     []interface{} // bind parameters
+	Duration time.Duration // for queries, until the rows were closed
+	Rows     int64         // rows affected or returned, -1 when unknown
+	Caller   string        // first function outside database/sql, GORM and this package
+	Err      error
+}
+
+// Logger receives an Entry after each statement completes.
+type Logger interface {
+	LogQuery(ctx context.Context, entry Entry)
+}
+
+// Func adapts a function to the Logger interface.
+type Func func(ctx context.Context, entry Entry)
+
+func (f Func) LogQuery(ctx context.Context, entry Entry) {
+	f(ctx, entry)
+}
+
+// Multi sends every entry to each of loggers in order. Nil loggers are skipped,
+// and Multi returns nil when every logger is nil.
+func Multi(loggers ...Logger) Logger {
+	var active multi
+	for _, logger := range loggers {
+		if logger != nil {
+			active = append(active, logger)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	}
+	return active
+}
+
+type multi []Logger
+
+func (m multi) LogQuery(ctx context.Context, entry Entry) {
+	for _, logger := range m {
+		logger.LogQuery(ctx, entry)
+	}
+}
+
+func (m multi) IgnoresCaller() bool {
+	for _, logger := range m {
+		if !ignoresCaller(logger) {
+			return false
+		}
+	}
+	return true
+}
+
+// callerIgnorer is implemented by loggers that never read Entry.Caller, which
+// is costly to compute.
+type callerIgnorer interface {
+	IgnoresCaller() bool
+}
+
+func ignoresCaller(logger Logger) bool {
+	ignorer, ok := logger.(callerIgnorer)
+	return ok && ignorer.IgnoresCaller()
+}
+
+// skippedPackages are the callers that issue statements on behalf of the code
+// being observed.
+var skippedPackages = []string{"database/sql.", "runtime.", "m/utils/querylog.", "gorm.io/", "github.com/lib/pq.", "github.com/jackc/pgx/"}
+
+// caller returns "function:line" for the first frame outside skippedPackages,
+// test files excepted.
+func caller() string {
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if !isSkipped(frame.Function) || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.Function + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func isSkipped(function string) bool {
+	for _, prefix := range skippedPackages {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package querylog
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"m/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeDriver affects three rows on every exec and returns two rows on every query.
+type fakeDriver struct{}
+
+type fakeConn struct{}
+
+type fakeStmt struct{}
+
+type fakeRows struct{ left int }
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return fakeConn{}, nil }
+func (fakeConn) Commit() error                       { return nil }
+func (fakeConn) Rollback() error                     { return nil }
+func (fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(3), nil
+}
+func (fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "missing") {
+		return nil, errors.New("relation does not exist")
+	}
+	return &fakeRows{left: 2}, nil
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{left: 2}, nil
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.left == 0 {
+		return io.EOF
+	}
+	r.left--
+	dest[0] = int64(r.left)
+	return nil
+}
+
+func init() {
+	sql.Register("querylog-fake", fakeDriver{})
+}
+
+func TestDriver(t *testing.T) {
+	var entries []Entry
+	db, err := Open("querylog-fake", "", Func(func(_ context.Context, entry Entry) {
+		entries = append(entries, entry)
+	}))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	db.Exec("UPDATE TASKS SET NAME = $1", "name")
+
+	rows, _ := db.Query("SELECT ID FROM TASKS")
+	for rows.Next() {
+	}
+	rows.Close()
+
+	db.Query("SELECT ID FROM missing")
+
+	tx, _ := db.Begin()
+	stmt, _ := tx.Prepare("DELETE FROM TASKS WHERE ID = $1")
+	stmt.Exec(1)
+	stmt.Close()
+	tx.Commit()
+
+	expected := []struct {
+		op   string
+		rows int64
+		err  bool
+	}{
+		{"exec", 3, false},
+		{"query", 2, false},
+		{"query", -1, true},
+		{"begin", -1, false},
+		{"prepare", -1, false},
+		{"exec", 1, false},
+		{"commit", -1, false},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d: %+v", len(expected), len(entries), entries)
+	}
+	for i, want := range expected {
+		got := entries[i]
+		if got.Op != want.op || got.Rows != want.rows || (got.Err != nil) != want.err {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, want, got)
+		}
+		if !strings.Contains(got.Caller, "TestDriver") {
+			t.Errorf("Entry %d: expected the caller to be the test, got %q", i, got.Caller)
+		}
+	}
+	if len(entries[0].Args) != 1 || entries[0].Args[0] != "name" {
+		t.Errorf("Expected the exec arguments to be recorded, got %v", entries[0].Args)
+	}
+}
+
+func TestDriverKeepsSensitiveArgs(t *testing.T) {
+	var logged []string
+	db, _ := Open("querylog-fake", "", Func(func(_ context.Context, entry Entry) {
+		logged = append(logged, utils.FormatQuery(entry.Query, entry.Args...))
+	}))
+	defer db.Close()
+
+	db.Exec("UPDATE USERS SET PASSWORD = $1 WHERE NAME = $2", utils.Sensitive{Arg: "hunter2"}, "alice")
+	stmt, _ := db.Prepare("UPDATE USERS SET PASSWORD = $1")
+	stmt.Exec(utils.Sensitive{Arg: "hunter2"})
+	stmt.Close()
+
+	expected := []string{
+		"UPDATE USERS SET PASSWORD = '<redacted>' WHERE NAME = 'alice'",
+		"UPDATE USERS SET PASSWORD = $1",
+		"UPDATE USERS SET PASSWORD = '<redacted>'",
+	}
+	if strings.Join(logged, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %q, got %q", expected, logged)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelWarn})
+	logger := Multi(nil, NewSlog(slog.New(handler), SlogOptions{SlowThreshold: 10 * time.Millisecond, Interpolate: true}))
+
+	ctx := context.Background()
+	logger.LogQuery(ctx, Entry{Op: "query", Query: "SELECT 1", Duration: time.Millisecond})
+	if buffer.Len() != 0 {
+		t.Errorf("Expected fast queries below the handler level to be dropped, got %s", buffer.String())
+	}
+
+	logger.LogQuery(ctx, Entry{Op: "exec", Query: "DELETE FROM TASKS WHERE ID = $1", Args: []interface{}{7}, Duration: time.Second, Rows: 1})
+	output := buffer.String()
+	for _, fragment := range []string{`"level":"WARN"`, `"msg":"slow query"`, `"query":"DELETE FROM TASKS WHERE ID = 7"`, `"rows":1`} {
+		if !strings.Contains(output, fragment) {
+			t.Errorf("Expected %s in %s", fragment, output)
+		}
+	}
+
+	buffer.Reset()
+	redactFirst := func(position int, _ interface{}) bool { return position == 1 }
+	logger = NewSlog(slog.New(handler), SlogOptions{Level: slog.LevelWarn, Redact: redactFirst})
+	logger.LogQuery(ctx, Entry{Op: "exec", Query: "UPDATE USERS SET PASSWORD = $1, NAME = $2", Args: []interface{}{"hunter2", "alice"}})
+	if output := buffer.String(); strings.Contains(output, "hunter2") || !strings.Contains(output, `"args":["<redacted>","alice"]`) {
+		t.Errorf("Expected the first argument to be redacted in %s", output)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	counter := &Counter{}
+	var callers []string
+	db, _ := Open("querylog-fake", "", Multi(counter, Func(func(_ context.Context, entry Entry) {
+		callers = append(callers, entry.Caller)
+	})))
+	defer db.Close()
+
+	queries, err := counter.Measure(func() error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		tx.Exec("DELETE FROM TASKS")
+		return tx.Commit()
+	})
+	if err != nil || queries != 3 {
+		t.Errorf("Expected 3 round trips, got %d, %v", queries, err)
+	}
+	if ops := counter.ByOp(); ops["begin"] != 1 || ops["exec"] != 1 || ops["commit"] != 1 {
+		t.Errorf("Unexpected counts by op: %v", ops)
+	}
+	if len(callers) != 3 || callers[0] == "" {
+		t.Errorf("Expected callers for the other logger, got %q", callers)
+	}
+	if !ignoresCaller(counter) || ignoresCaller(Multi(counter, NewSlog(slog.Default(), SlogOptions{}))) {
+		t.Error("Expected only a lone counter to skip caller lookup")
+	}
+	if Multi(nil, nil) != nil {
+		t.Error("Expected Multi of nil loggers to be nil")
+	}
+}
+
+func TestPgxTracer(t *testing.T) {
+	var entries []Entry
+	tracer := Pgx(Func(func(_ context.Context, entry Entry) { entries = append(entries, entry) }))
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1", Args: []interface{}{1}})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 2")})
+
+	ctx = tracer.TraceBatchStart(context.Background(), nil, pgx.TraceBatchStartData{})
+	tracer.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "INSERT INTO a VALUES (1)", CommandTag: pgconn.NewCommandTag("INSERT 0 1")})
+	tracer.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "INSERT INTO b VALUES (1)", Err: errors.New("duplicate key")})
+	tracer.TraceBatchEnd(ctx, nil, pgx.TraceBatchEndData{})
+
+	ctx = tracer.TraceCopyFromStart(context.Background(), nil, pgx.TraceCopyFromStartData{TableName: pgx.Identifier{"resources"}, ColumnNames: []string{"id", "name"}})
+	tracer.TraceCopyFromEnd(ctx, nil, pgx.TraceCopyFromEndData{CommandTag: pgconn.NewCommandTag("COPY 500")})
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected one entry per round trip, got %+v", entries)
+	}
+	if query := entries[0]; query.Op != "query" || query.Query != "SELECT 1" || query.Rows != 2 || len(query.Args) != 1 {
+		t.Errorf("Unexpected query entry: %+v", query)
+	}
+	if batch := entries[1]; batch.Op != "batch" || batch.Query != "INSERT INTO a VALUES (1);\nINSERT INTO b VALUES (1)" || batch.Rows != 1 || batch.Err == nil {
+		t.Errorf("Unexpected batch entry: %+v", batch)
+	}
+	if copied := entries[2]; copied.Op != "copy" || copied.Query != `COPY "resources" (id, name) FROM STDIN` || copied.Rows != 500 {
+		t.Errorf("Unexpected copy entry: %+v", copied)
+	}
+}
@@ -0,0 +1,76 @@
+package querylog
+
+import (
+	"context"
+	"log/slog"
+	"m/utils"
+	"time"
+)
+
+type SlogOptions struct {
+	// Level is used for ordinary statements. Failed statements are logged at
+	// slog.LevelError and slow ones at slog.LevelWarn.
+	Level slog.Level
+	// SlowThreshold marks statements that take at least this long as slow.
+	// Zero disables slow-query detection.
+	SlowThreshold time.Duration
+	// Interpolate logs the statement with its arguments substituted, ready to
+	// be pasted into psql, instead of the query and args separately.
+	Interpolate bool
+	// Redact hides the arguments for which it returns true, in addition to
+	// those wrapped in utils.Sensitive. Positions start at 1.
+	Redact func(position int, arg interface{}) bool
+}
+
+// SlogLogger writes entries as structured log/slog records.
+type SlogLogger struct {
+	logger  *slog.Logger
+	options SlogOptions
+}
+
+func NewSlog(logger *slog.Logger, options SlogOptions) *SlogLogger {
+	return &SlogLogger{logger: logger, options: options}
+}
+
+func (l *SlogLogger) LogQuery(ctx context.Context, entry Entry) {
+	level, message := l.options.Level, "query"
+	switch {
+	case entry.Err != nil:
+		level, message = slog.LevelError, "query failed"
+	case l.options.SlowThreshold > 0 && entry.Duration >= l.options.SlowThreshold:
+		level, message = slog.LevelWarn, "slow query"
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{slog.String("op", entry.Op)}
+	if l.options.Interpolate {
+		attrs = append(attrs, slog.String("query", utils.FormatQueryRedacted(entry.Query, l.options.Redact, entry.Args...)))
+	} else {
+		attrs = append(attrs, slog.String("query", entry.Query), slog.Any("args", l.redact(entry.Args)))
+	}
+	attrs = append(attrs,
+		slog.Duration("duration", entry.Duration),
+		slog.Int64("rows", entry.Rows),
+		slog.String("caller", entry.Caller),
+	)
+	if entry.Err != nil {
+		attrs = append(attrs, slog.Any("error", entry.Err))
+	}
+	l.logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+func (l *SlogLogger) redact(args []interface{}) []interface{} {
+	if l.options.Redact == nil {
+		return args
+	}
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if l.options.Redact(i+1, arg) {
+			arg = utils.Sensitive{Arg: arg}
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
@@ -0,0 +1,163 @@
+package results
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Value returns the measurement of the record in unit: ns/op, B/op,
+// allocs/op or one of its Metrics.
+func (r Record) Value(unit string) (float64, bool) {
+	switch unit {
+	case "ns/op":
+		return r.NsPerOp, true
+	case "B/op":
+		return float64(r.BytesPerOp), true
+	case "allocs/op":
+		return float64(r.AllocsPerOp), true
+	}
+	value, ok := r.Metrics[unit]
+	return value, ok
+}
+
+// Name identifies the benchmark as "approach/operation", followed by its
+// params in the form of sub-benchmark names and by the -procs suffix of go
+// test when it ran on more than one CPU: "GORM/ReadProject/pool=4-8".
+func (r Record) Name() string {
+	name := r.Approach + "/" + r.Operation
+	for _, param := range FormatParams(r.Params) {
+		name += "/" + param
+	}
+	if r.Procs > 1 {
+		name += fmt.Sprintf("-%d", r.Procs)
+	}
+	return name
+}
+
+// HigherIsBetter lists the units for which an increase is an improvement.
+var HigherIsBetter = map[string]bool{"hit-ratio": true}
+
+// Comparison is the change of one benchmark between two runs.
+type Comparison struct {
+	Approach  string  `json:"approach"`
+	Operation string  `json:"operation"`
+	Name      string  `json:"name"`
+	Old       Summary `json:"old"`
+	New       Summary `json:"new"`
+	Delta     float64 `json:"delta"` // relative change of the medians, 0.1 for +10%
+	P         float64 `json:"p"`
+}
+
+// Significant reports whether the change is unlikely to be noise at level alpha.
+func (c Comparison) Significant(alpha float64) bool {
+	return c.P < alpha
+}
+
+// Regressed reports whether the change in unit is significant at level alpha
+// and worse than threshold, a relative change such as 0.05.
+func (c Comparison) Regressed(unit string, alpha, threshold float64) bool {
+	delta := c.Delta
+	if HigherIsBetter[unit] {
+		delta = -delta
+	}
+	return c.Significant(alpha) && delta > threshold
+}
+
+// Compare matches the benchmarks present in both runs and compares their
+// values in unit. Each run may hold several records per benchmark, from
+// go test -count.
+func Compare(old, new []Record, unit string, confidence float64) []Comparison {
+	oldSamples, _ := samples(old, unit)
+	newSamples, names := samples(new, unit)
+
+	var comparisons []Comparison
+	for _, name := range names {
+		before, ok := oldSamples[name]
+		if !ok {
+			continue
+		}
+		after := newSamples[name]
+		comparison := Comparison{
+			Approach:  after.record.Approach,
+			Operation: after.record.Operation,
+			Name:      name,
+			Old:       Summarize(before.values, confidence),
+			New:       Summarize(after.values, confidence),
+			P:         MannWhitney(before.values, after.values),
+		}
+		if comparison.Old.Median != 0 {
+			comparison.Delta = (comparison.New.Median - comparison.Old.Median) / comparison.Old.Median
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+type sample struct {
+	record Record
+	values []float64
+}
+
+// samples groups the values in unit by benchmark name, keeping the order in
+// which the names first appear.
+func samples(records []Record, unit string) (map[string]*sample, []string) {
+	byName := make(map[string]*sample)
+	var names []string
+	for _, record := range records {
+		value, ok := record.Value(unit)
+		if !ok {
+			continue
+		}
+		name := record.Name()
+		s, exists := byName[name]
+		if !exists {
+			s = &sample{record: record}
+			byName[name] = s
+			names = append(names, name)
+		}
+		s.values = append(s.values, value)
+	}
+	return byName, names
+}
+
+// SaveHistory writes the records of a run into dir, named by its timestamp
+// and commit so that the files sort chronologically.
+func SaveHistory(dir string, run Run, records []Record) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := run.Timestamp.UTC().Format("20060102T150405Z")
+	if run.Commit != "" {
+		name += "_" + run.Commit
+	}
+	path := filepath.Join(dir, name+".json")
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := WriteJSON(file, records); err != nil {
+		file.Close()
+		return "", err
+	}
+	return path, file.Close()
+}
+
+// History lists the runs saved in dir, oldest first.
+func History(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,255 @@
+// Package results parses the output of go test -bench into records and writes
+// them as JSON or CSV for analysis.
+package results
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is one benchmark result. Metrics holds the units reported with
+// b.ReportMetric, such as queries/op, and Params the parameters of the
+// benchmark matrix that produced it, such as the dataset.
+type Record struct {
+	Approach    string             `json:"approach"`
+	Operation   string             `json:"operation"`
+	Params      map[string]string  `json:"params,omitempty"`
+	Procs       int                `json:"procs"`
+	Iterations  int64              `json:"iterations"`
+	NsPerOp     float64            `json:"nsPerOp"`
+	BytesPerOp  int64              `json:"bytesPerOp"`
+	AllocsPerOp int64              `json:"allocsPerOp"`
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+	GoVersion   string             `json:"goVersion"`
+	GOOS        string             `json:"goos"`
+	GOARCH      string             `json:"goarch"`
+	CPU         string             `json:"cpu,omitempty"`
+	Commit      string             `json:"commit"`
+	Timestamp   time.Time          `json:"timestamp"`
+}
+
+// Run describes the benchmark run shared by its records.
+type Run struct {
+	GoVersion string
+	Commit    string
+	Timestamp time.Time
+}
+
+// Parse reads the output of go test -bench -benchmem for approach, labelling
+// the records with params. Without an approach, the first sub-benchmark names
+// it, as in the suite: BenchmarkReadProject/GORM. Lines other than results
+// and the goos, goarch and cpu headers are ignored.
+func Parse(r io.Reader, approach string, params map[string]string, run Run) ([]Record, error) {
+	var records []Record
+	var goos, goarch, cpu string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "goos: "):
+			goos = strings.TrimPrefix(line, "goos: ")
+		case strings.HasPrefix(line, "goarch: "):
+			goarch = strings.TrimPrefix(line, "goarch: ")
+		case strings.HasPrefix(line, "cpu: "):
+			cpu = strings.TrimPrefix(line, "cpu: ")
+		case strings.HasPrefix(line, "Benchmark"):
+			record, ok := parseLine(line)
+			if !ok {
+				continue
+			}
+			record.Params = params
+			if approach != "" {
+				record.Approach = approach
+			} else {
+				record.Approach, record.Operation = splitApproach(record.Operation)
+			}
+			record.GoVersion, record.Commit, record.Timestamp = run.GoVersion, run.Commit, run.Timestamp
+			record.GOOS, record.GOARCH, record.CPU = goos, goarch, cpu
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// parseLine reads "BenchmarkReadProject-8  100  12345 ns/op  1.00 queries/op
+// 2048 B/op  30 allocs/op".
+func parseLine(line string) (Record, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || len(fields)%2 != 0 {
+		return Record{}, false
+	}
+	iterations, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return Record{}, false
+	}
+
+	record := Record{Iterations: iterations, Procs: 1}
+	record.Operation = strings.TrimPrefix(fields[0], "Benchmark")
+	if dash := strings.LastIndexByte(record.Operation, '-'); dash >= 0 {
+		if procs, err := strconv.Atoi(record.Operation[dash+1:]); err == nil {
+			record.Operation, record.Procs = record.Operation[:dash], procs
+		}
+	}
+
+	for i := 2; i < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return Record{}, false
+		}
+		switch unit := fields[i+1]; unit {
+		case "ns/op":
+			record.NsPerOp = value
+		case "B/op":
+			record.BytesPerOp = int64(value)
+		case "allocs/op":
+			record.AllocsPerOp = int64(value)
+		default:
+			if record.Metrics == nil {
+				record.Metrics = make(map[string]float64)
+			}
+			record.Metrics[unit] = value
+		}
+	}
+	return record, true
+}
+
+// splitApproach takes the approach out of the operation of a suite benchmark:
+// "ReadProject/GORM/x" is the ReadProject/x operation of GORM.
+func splitApproach(name string) (approach, operation string) {
+	segments := strings.SplitN(name, "/", 3)
+	if len(segments) < 2 {
+		return "", name
+	}
+	approach = segments[1]
+	return approach, strings.Join(append(segments[:1], segments[2:]...), "/")
+}
+
+func WriteJSON(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// ReadJSON loads the records written by WriteJSON.
+func ReadJSON(path string) ([]Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	err = json.Unmarshal(data, &records)
+	return records, err
+}
+
+var csvHeader = []string{
+	"approach", "operation", "procs", "iterations", "ns_per_op", "bytes_per_op", "allocs_per_op",
+	"go_version", "goos", "goarch", "cpu", "commit", "timestamp",
+}
+
+// WriteCSV writes one row per record. Each parameter found in Params, then
+// each unit found in Metrics, gets its own column after the fixed ones, named
+// like dataset and queries_per_op and empty where a record lacks it.
+func WriteCSV(w io.Writer, records []Record) error {
+	var params, units []string
+	seenParams, seenUnits := make(map[string]bool), make(map[string]bool)
+	for _, record := range records {
+		for param := range record.Params {
+			if !seenParams[param] {
+				seenParams[param] = true
+				params = append(params, param)
+			}
+		}
+		for unit := range record.Metrics {
+			if !seenUnits[unit] {
+				seenUnits[unit] = true
+				units = append(units, unit)
+			}
+		}
+	}
+	sort.Strings(params)
+	sort.Strings(units)
+
+	writer := csv.NewWriter(w)
+	header := append([]string(nil), csvHeader...)
+	header = append(header, params...)
+	for _, unit := range units {
+		header = append(header, ColumnName(unit))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		row := []string{
+			r.Approach, r.Operation, strconv.Itoa(r.Procs), strconv.FormatInt(r.Iterations, 10),
+			formatFloat(r.NsPerOp), strconv.FormatInt(r.BytesPerOp, 10), strconv.FormatInt(r.AllocsPerOp, 10),
+			r.GoVersion, r.GOOS, r.GOARCH, r.CPU, r.Commit, r.Timestamp.Format(time.RFC3339),
+		}
+		for _, param := range params {
+			row = append(row, r.Params[param])
+		}
+		for _, unit := range units {
+			value, ok := r.Metrics[unit]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, formatFloat(value))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// FormatParams formats params as key=value pairs sorted by key.
+func FormatParams(params map[string]string) []string {
+	pairs := make([]string, 0, len(params))
+	for key, value := range params {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// ColumnName turns a benchmark unit into a CSV column: "queries/op" becomes
+// "queries_per_op".
+func ColumnName(unit string) string {
+	return strings.NewReplacer("/", "_per_", "-", "_", " ", "_").Replace(unit)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// WriteFiles writes the records to path.json and path.csv.
+func WriteFiles(path string, records []Record) error {
+	for _, output := range []struct {
+		ext   string
+		write func(io.Writer, []Record) error
+	}{{".json", WriteJSON}, {".csv", WriteCSV}} {
+		file, err := os.Create(path + output.ext)
+		if err != nil {
+			return err
+		}
+		if err := output.write(file, records); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write %s: %v", file.Name(), err)
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
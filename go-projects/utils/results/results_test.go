@@ -0,0 +1,82 @@
+package results
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const output = `goos: linux
+goarch: amd64
+pkg: m/tests/GORM
+cpu: AMD Ryzen 7 5800X 8-Core Processor
+BenchmarkInsertProject-16        	     100	  12345678 ns/op	         6.000 queries/op	  104857 B/op	    1234 allocs/op
+BenchmarkReadProjectCached-16    	    5000	    250000 ns/op	         0.9800 hit-ratio	         0.02000 queries/op	    2048 B/op	      30 allocs/op
+BenchmarkReadProject
+--- FAIL: BenchmarkDeleteProject
+PASS
+ok  	m/tests/GORM	12.345s
+`
+
+func TestParse(t *testing.T) {
+	run := Run{GoVersion: "go1.21.0", Commit: "abc1234", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	records, err := Parse(strings.NewReader(output), "GORM", nil, run)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %+v", records)
+	}
+
+	insert := records[0]
+	if insert.Approach != "GORM" || insert.Operation != "InsertProject" || insert.Procs != 16 || insert.Iterations != 100 ||
+		insert.NsPerOp != 12345678 || insert.BytesPerOp != 104857 || insert.AllocsPerOp != 1234 || insert.Metrics["queries/op"] != 6 {
+		t.Errorf("Unexpected record: %+v", insert)
+	}
+	if insert.GOOS != "linux" || insert.CPU != "AMD Ryzen 7 5800X 8-Core Processor" || insert.Commit != "abc1234" || insert.GoVersion != "go1.21.0" {
+		t.Errorf("Expected the run details in the record, got %+v", insert)
+	}
+	if records[1].Metrics["hit-ratio"] != 0.98 {
+		t.Errorf("Expected the hit ratio, got %+v", records[1].Metrics)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	records, _ := Parse(strings.NewReader(output), "GORM", map[string]string{"pool": "4"}, Run{Commit: "abc1234", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)})
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, records); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := []string{
+		"approach,operation,procs,iterations,ns_per_op,bytes_per_op,allocs_per_op,go_version,goos,goarch,cpu,commit,timestamp,pool,hit_ratio,queries_per_op",
+		"GORM,InsertProject,16,100,12345678,104857,1234,,linux,amd64,AMD Ryzen 7 5800X 8-Core Processor,abc1234,2024-05-01T12:00:00Z,4,,6",
+		"GORM,ReadProjectCached,16,5000,250000,2048,30,,linux,amd64,AMD Ryzen 7 5800X 8-Core Processor,abc1234,2024-05-01T12:00:00Z,4,0.98,0.02",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got:\n%s", len(expected), buf.String())
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Line %d:\nexpected %s\ngot      %s", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestParseSuite(t *testing.T) {
+	output := `BenchmarkReadProject/GORM-8         	     100	  12345678 ns/op	         4.000 queries/op	  104857 B/op	    1234 allocs/op
+BenchmarkReadProject/DirectStruct/x-8	     200	   6172839 ns/op	         1.000 queries/op	   52428 B/op	     617 allocs/op
+`
+	records, err := Parse(strings.NewReader(output), "", nil, Run{})
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %+v (%v)", records, err)
+	}
+	if records[0].Approach != "GORM" || records[0].Operation != "ReadProject" || records[0].Procs != 8 {
+		t.Errorf("Unexpected record: %+v", records[0])
+	}
+	if records[1].Approach != "DirectStruct" || records[1].Operation != "ReadProject/x" {
+		t.Errorf("Unexpected record: %+v", records[1])
+	}
+}
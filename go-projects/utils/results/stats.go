@@ -0,0 +1,155 @@
+package results
+
+import (
+	"math"
+	"sort"
+)
+
+// Summary describes a sample by its median and a confidence interval for
+// the median.
+type Summary struct {
+	N      int     `json:"n"`
+	Median float64 `json:"median"`
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+}
+
+// Summarize returns the median of values with a distribution-free interval
+// from order statistics at the given confidence, such as 0.95. Samples too
+// small to reach that confidence get their full range.
+func Summarize(values []float64, confidence float64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+
+	summary := Summary{N: n, Low: sorted[0], High: sorted[n-1]}
+	if n%2 == 1 {
+		summary.Median = sorted[n/2]
+	} else {
+		summary.Median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	// The k-th smallest value is below the median unless fewer than k values
+	// are, which has probability P(Binomial(n, 1/2) < k).
+	tail := (1 - confidence) / 2
+	for k := 1; k <= n/2; k++ {
+		if binomialCDF(n, k-1) > tail {
+			break
+		}
+		summary.Low, summary.High = sorted[k-1], sorted[n-k]
+	}
+	return summary
+}
+
+// binomialCDF is P(Binomial(n, 1/2) <= k).
+func binomialCDF(n, k int) float64 {
+	sum, term := 0.0, math.Pow(0.5, float64(n))
+	for i := 0; i <= k; i++ {
+		sum += term
+		term = term * float64(n-i) / float64(i+1)
+	}
+	return sum
+}
+
+// MannWhitney returns the two-sided p-value of the Mann-Whitney U test for
+// samples x and y: the probability of a difference in ranks at least as large
+// if both came from the same distribution. It is exact for small samples
+// without ties and uses the normal approximation otherwise.
+func MannWhitney(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type observation struct {
+		value float64
+		first bool
+	}
+	all := make([]observation, 0, n1+n2)
+	for _, value := range x {
+		all = append(all, observation{value, true})
+	}
+	for _, value := range y {
+		all = append(all, observation{value, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// Ranks start at 1; tied values share the mean of their ranks.
+	rankSum, tieCorrection, ties := 0.0, 0.0, false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieCorrection += t*t*t - t
+		}
+		i = j
+	}
+	u := rankSum - float64(n1*(n1+1))/2
+
+	if !ties && n1+n2 <= 50 {
+		return exactMannWhitney(n1, n2, u)
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieCorrection/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		return 1
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// exactMannWhitney computes the p-value from the distribution of U over all
+// arrangements of n1 and n2 distinct values.
+func exactMannWhitney(n1, n2 int, u float64) float64 {
+	// counts[i][j][v] is the number of arrangements of i and j values with
+	// U = v; the last value belongs to y (U unchanged) or to x (U grows by j).
+	counts := make([][][]float64, n1+1)
+	for i := range counts {
+		counts[i] = make([][]float64, n2+1)
+		for j := range counts[i] {
+			counts[i][j] = make([]float64, i*j+1)
+			if i == 0 || j == 0 {
+				counts[i][j][0] = 1
+				continue
+			}
+			for v := range counts[i][j] {
+				if v < len(counts[i][j-1]) {
+					counts[i][j][v] += counts[i][j-1][v]
+				}
+				if v >= j && v-j < len(counts[i-1][j]) {
+					counts[i][j][v] += counts[i-1][j][v-j]
+				}
+			}
+		}
+	}
+
+	distribution := counts[n1][n2]
+	total, below, above := 0.0, 0.0, 0.0
+	for v, count := range distribution {
+		total += count
+		if float64(v) <= u {
+			below += count
+		}
+		if float64(v) >= u {
+			above += count
+		}
+	}
+	return math.Min(1, 2*math.Min(below, above)/total)
+}
@@ -0,0 +1,100 @@
+package results
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	small := Summarize([]float64{3, 1, 2}, 0.95)
+	if small != (Summary{N: 3, Median: 2, Low: 1, High: 3}) {
+		t.Errorf("Expected the range of a small sample, got %+v", small)
+	}
+
+	// For n = 10, P(Binomial(10, 1/2) <= 1) = 11/1024 is the largest tail
+	// under 0.025, so the interval runs from the 2nd to the 9th value.
+	values := []float64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5}
+	summary := Summarize(values, 0.95)
+	if summary != (Summary{N: 10, Median: 5.5, Low: 2, High: 9}) {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+func TestMannWhitney(t *testing.T) {
+	tests := []struct {
+		name     string
+		x, y     []float64
+		expected float64
+	}{
+		// Complete separation: 2 of the 252 arrangements are as extreme.
+		{"exact separated", []float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 2.0 / 252},
+		// U = 3 and 7 of the 20 arrangements have U <= 3.
+		{"exact interleaved", []float64{1, 3, 5}, []float64{2, 4, 6}, 2 * 7.0 / 20},
+		{"identical", []float64{4, 4, 4}, []float64{4, 4, 4}, 1},
+	}
+	for _, test := range tests {
+		if p := MannWhitney(test.x, test.y); math.Abs(p-test.expected) > 1e-9 {
+			t.Errorf("%s: expected p = %v, got %v", test.name, test.expected, p)
+		}
+	}
+
+	// With ties the normal approximation applies.
+	x := []float64{1, 1, 2, 2, 3, 3, 4, 4}
+	y := []float64{5, 5, 6, 6, 7, 7, 8, 8}
+	if p := MannWhitney(x, y); p > 0.001 {
+		t.Errorf("Expected a significant difference with ties, got p = %v", p)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var old, new []Record
+	for i, ns := range []float64{100, 101, 99, 102, 98} {
+		old = append(old,
+			Record{Approach: "GORM", Operation: "ReadProject", NsPerOp: ns},
+			Record{Approach: "GORM", Operation: "DeleteProject", NsPerOp: 50 + float64(i)})
+		new = append(new,
+			Record{Approach: "GORM", Operation: "ReadProject", NsPerOp: ns + 20},
+			Record{Approach: "GORM", Operation: "DeleteProject", NsPerOp: 52 - float64(i)},
+			Record{Approach: "PGX", Operation: "ReadProject", NsPerOp: ns})
+	}
+
+	comparisons := Compare(old, new, "ns/op", 0.95)
+	if len(comparisons) != 2 {
+		t.Fatalf("Expected the 2 benchmarks present in both runs, got %+v", comparisons)
+	}
+	read, remove := comparisons[0], comparisons[1]
+	if read.Name != "GORM/ReadProject" || math.Abs(read.Delta-0.2) > 1e-9 || !read.Regressed("ns/op", 0.05, 0.05) {
+		t.Errorf("Expected a regression of ReadProject, got %+v", read)
+	}
+	if remove.Regressed("ns/op", 0.05, 0.05) {
+		t.Errorf("Expected no regression of DeleteProject, got %+v", remove)
+	}
+	if (Comparison{Delta: -0.2, P: 0.01}).Regressed("hit-ratio", 0.05, 0.05) != true {
+		t.Error("Expected a lower hit ratio to be a regression")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	first := Run{Commit: "abc1234", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	second := Run{Commit: "def5678", Timestamp: time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC)}
+	for _, run := range []Run{second, first} {
+		if _, err := SaveHistory(dir, run, []Record{{Approach: "GORM", Operation: "ReadProject", Commit: run.Commit}}); err != nil {
+			t.Fatalf("SaveHistory failed: %v", err)
+		}
+	}
+
+	paths, err := History(dir)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[0]) != "20240501T120000Z_abc1234.json" {
+		t.Fatalf("Expected the runs oldest first, got %v", paths)
+	}
+	records, err := ReadJSON(paths[1])
+	if err != nil || len(records) != 1 || records[0].Commit != "def5678" {
+		t.Errorf("Unexpected records %+v (%v)", records, err)
+	}
+}
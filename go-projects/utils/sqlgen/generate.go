@@ -0,0 +1,245 @@
+package sqlgen
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+)
+
+// GoType is the Go type of a field: a plain type for NOT NULL columns and a
+// sql.Null type otherwise. DECIMAL and NUMERIC map to float64, like the
+// entities of the other approaches, and INTERVAL to its text form.
+func (f Field) GoType() (string, error) {
+	base := strings.ToUpper(strings.Fields(f.Column.Type)[0])
+	if i := strings.IndexByte(base, '('); i >= 0 {
+		base = base[:i]
+	}
+
+	var plain, null string
+	switch base {
+	case "SMALLINT", "INT2", "INTEGER", "INT", "INT4", "SERIAL":
+		plain, null = "int32", "sql.NullInt32"
+	case "BIGINT", "INT8", "BIGSERIAL":
+		plain, null = "int64", "sql.NullInt64"
+	case "DECIMAL", "NUMERIC", "REAL", "FLOAT4", "DOUBLE", "FLOAT8":
+		plain, null = "float64", "sql.NullFloat64"
+	case "VARCHAR", "CHARACTER", "CHAR", "TEXT", "INTERVAL", "UUID":
+		plain, null = "string", "sql.NullString"
+	case "DATE", "TIMESTAMP", "TIMESTAMPTZ", "TIME":
+		plain, null = "time.Time", "sql.NullTime"
+	case "BOOLEAN", "BOOL":
+		plain, null = "bool", "sql.NullBool"
+	default:
+		return "", fmt.Errorf("column %s has unsupported type %s", f.Column.Name, f.Column.Type)
+	}
+	if f.Nullable {
+		return null, nil
+	}
+	return plain, nil
+}
+
+// Generate writes the Go source of package pkg with a Queries type holding
+// one method per query. source is the name of the query file, recorded in
+// the header.
+func Generate(pkg string, source string, queries []Query) ([]byte, error) {
+	var body strings.Builder
+	usesTime := false
+	for _, query := range queries {
+		for _, field := range append(append([]Field{}, query.Params...), query.Results...) {
+			goType, err := field.GoType()
+			if err != nil {
+				return nil, fmt.Errorf("query %s: %w", query.Name, err)
+			}
+			usesTime = usesTime || goType == "time.Time"
+		}
+		if err := writeQuery(&body, query); err != nil {
+			return nil, fmt.Errorf("query %s: %w", query.Name, err)
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "// Code generated by sqlgen. DO NOT EDIT.\n// source: %s\n\n", source)
+	fmt.Fprintf(&out, "package %s\n\nimport (\n\t\"context\"\n\t\"database/sql\"\n", pkg)
+	if usesTime {
+		out.WriteString("\t\"time\"\n")
+	}
+	out.WriteString(`)
+
+// DBTX is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// New returns the queries running on db.
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+// Queries runs the generated queries.
+type Queries struct {
+	db DBTX
+}
+
+// WithTx returns the queries running in tx.
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return &Queries{db: tx}
+}
+`)
+	out.WriteString(body.String())
+
+	formatted, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+func writeQuery(out *strings.Builder, query Query) error {
+	constName := lowerName(query.Name)
+	fmt.Fprintf(out, "\nconst %s = `-- name: %s :%s\n%s\n`\n", constName, query.Name, query.Cmd, query.SQL)
+
+	// Parameters: none, one passed directly, or a Params struct.
+	params := "ctx context.Context"
+	var args []string
+	switch len(query.Params) {
+	case 0:
+	case 1:
+		goType, _ := query.Params[0].GoType()
+		name := lowerName(query.Params[0].Name)
+		params += ", " + name + " " + goType
+		args = append(args, name)
+	default:
+		paramsType := query.Name + "Params"
+		if err := writeStruct(out, paramsType, query.Params); err != nil {
+			return err
+		}
+		params += ", arg " + paramsType
+		for _, field := range query.Params {
+			args = append(args, "arg."+field.Name)
+		}
+	}
+	callArgs := constName
+	if len(args) > 0 {
+		callArgs += ", " + strings.Join(args, ", ")
+	}
+
+	// Results: a single column is returned as is, several in a Row struct.
+	var itemType string
+	var scan []string
+	switch len(query.Results) {
+	case 0:
+	case 1:
+		itemType, _ = query.Results[0].GoType()
+		scan = []string{"&i"}
+	default:
+		itemType = query.Name + "Row"
+		if err := writeStruct(out, itemType, query.Results); err != nil {
+			return err
+		}
+		for _, field := range query.Results {
+			scan = append(scan, "&i."+field.Name)
+		}
+	}
+
+	out.WriteString("\n")
+	for _, line := range query.Doc {
+		fmt.Fprintf(out, "// %s\n", line)
+	}
+	switch query.Cmd {
+	case "exec":
+		fmt.Fprintf(out, `func (q *Queries) %s(%s) error {
+	_, err := q.db.ExecContext(ctx, %s)
+	return err
+}
+`, query.Name, params, callArgs)
+	case "one":
+		fmt.Fprintf(out, `func (q *Queries) %s(%s) (%s, error) {
+	row := q.db.QueryRowContext(ctx, %s)
+	var i %s
+	err := row.Scan(%s)
+	return i, err
+}
+`, query.Name, params, itemType, callArgs, itemType, strings.Join(scan, ", "))
+	case "many":
+		fmt.Fprintf(out, `func (q *Queries) %s(%s) ([]%s, error) {
+	rows, err := q.db.QueryContext(ctx, %s)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []%s
+	for rows.Next() {
+		var i %s
+		if err := rows.Scan(%s); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+`, query.Name, params, itemType, callArgs, itemType, itemType, strings.Join(scan, ", "))
+	}
+	return nil
+}
+
+func writeStruct(out *strings.Builder, name string, fields []Field) error {
+	fmt.Fprintf(out, "\ntype %s struct {\n", name)
+	for _, field := range fields {
+		goType, err := field.GoType()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\t%s %s\n", field.Name, goType)
+	}
+	out.WriteString("}\n")
+	return nil
+}
+
+// goName turns a SQL name into an exported Go name: PROJECT_ID is ProjectID.
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.ToLower(name), "_") {
+		if part == "" {
+			continue
+		}
+		if part == "id" {
+			b.WriteString("ID")
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if b.Len() == 0 || !token.IsIdentifier(b.String()) {
+		return "Column" + b.String()
+	}
+	return b.String()
+}
+
+// lowerName turns an exported Go name into an unexported one: ProjectID is
+// projectID and ID is id.
+func lowerName(name string) string {
+	upper := 0
+	for upper < len(name) && name[upper] >= 'A' && name[upper] <= 'Z' {
+		upper++
+	}
+	switch {
+	case upper == len(name):
+		name = strings.ToLower(name)
+	case upper > 1:
+		name = strings.ToLower(name[:upper-1]) + name[upper-1:]
+	default:
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	if token.IsKeyword(name) {
+		return name + "_"
+	}
+	return name
+}
@@ -0,0 +1,344 @@
+package sqlgen
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query is an annotated query with its parameters and result columns typed
+// against the schema.
+type Query struct {
+	Name string
+	// Cmd is one, many or exec.
+	Cmd string
+	// Doc holds the comment lines between the annotation and the statement.
+	Doc []string
+	SQL string
+	// Params are the $n placeholders in order.
+	Params  []Field
+	Results []Field
+}
+
+// Field is a parameter or result column of a query.
+type Field struct {
+	// Name is the exported Go name.
+	Name     string
+	Column   Column
+	Nullable bool
+}
+
+var (
+	annotation     = regexp.MustCompile(`^--\s*name:\s*(\w+)\s+:(one|many|exec)\s*$`)
+	tableRef       = regexp.MustCompile(`(?i)\b(?:(LEFT|FULL)\s+(?:OUTER\s+)?)?(FROM|JOIN|INTO|UPDATE)\s+(\w+)(?:\s+(?:AS\s+)?(\w+))?`)
+	comparison     = regexp.MustCompile(`(?i)(?:(\w+)\.)?(\w+)\s*(?:=|<>|!=|<=|>=|<|>)\s*\$(\d+)`)
+	resultItem     = regexp.MustCompile(`(?is)^(?:(\w+)\.)?(\w+)(?:\s+(?:AS\s+)?(\w+))?$`)
+	insertInto     = regexp.MustCompile(`(?is)^INSERT\s+INTO\s+\w+\s*\(`)
+	valuesWord     = regexp.MustCompile(`(?i)\bVALUES\s*\(`)
+	placeholder    = regexp.MustCompile(`^\$(\d+)$`)
+	anyPlaceholder = regexp.MustCompile(`\$(\d+)`)
+)
+
+// Words that can follow a table name without being its alias.
+var clauseWords = map[string]bool{
+	"WHERE": true, "SET": true, "ON": true, "USING": true, "LEFT": true, "RIGHT": true, "FULL": true,
+	"INNER": true, "CROSS": true, "JOIN": true, "ORDER": true, "GROUP": true, "HAVING": true, "LIMIT": true,
+	"OFFSET": true, "RETURNING": true, "VALUES": true, "SELECT": true, "DEFAULT": true, "UNION": true,
+}
+
+// ParseQueries reads the annotated queries of src and types them against
+// schema.
+func ParseQueries(src string, schema Schema) ([]Query, error) {
+	var queries []Query
+	var current *Query
+	var sqlLines []string
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		current.SQL = strings.TrimSuffix(strings.TrimSpace(strings.Join(sqlLines, "\n")), ";")
+		if err := current.analyze(schema); err != nil {
+			return fmt.Errorf("query %s: %w", current.Name, err)
+		}
+		queries = append(queries, *current)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if match := annotation.FindStringSubmatch(trimmed); match != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			current = &Query{Name: match[1], Cmd: match[2]}
+			sqlLines = nil
+			continue
+		}
+		if current == nil || strings.HasPrefix(trimmed, "--") {
+			if current != nil && len(sqlLines) == 0 {
+				current.Doc = append(current.Doc, strings.TrimSpace(strings.TrimPrefix(trimmed, "--")))
+			}
+			continue
+		}
+		if trimmed != "" || len(sqlLines) > 0 {
+			sqlLines = append(sqlLines, line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no annotated queries found")
+	}
+
+	names := make(map[string]bool)
+	for _, query := range queries {
+		if names[query.Name] {
+			return nil, fmt.Errorf("query %s defined twice", query.Name)
+		}
+		names[query.Name] = true
+	}
+	return queries, nil
+}
+
+// queryTable is a table referenced by a query, under its alias if it has one.
+type queryTable struct {
+	table    *Table
+	alias    string
+	nullable bool
+}
+
+func (q *Query) analyze(schema Schema) error {
+	if q.SQL == "" {
+		return fmt.Errorf("empty statement")
+	}
+	if strings.Contains(q.SQL, "`") {
+		return fmt.Errorf("statement contains a backquote")
+	}
+
+	tables, err := referencedTables(q.SQL, schema)
+	if err != nil {
+		return err
+	}
+
+	params := make(map[int]Field)
+	if insertInto.MatchString(q.SQL) {
+		if err := insertParams(q.SQL, tables[0].table, params); err != nil {
+			return err
+		}
+	}
+	for _, match := range comparison.FindAllStringSubmatch(q.SQL, -1) {
+		column, _, err := resolve(tables, match[1], match[2])
+		if err != nil {
+			return err
+		}
+		n, _ := strconv.Atoi(match[3])
+		if _, ok := params[n]; !ok {
+			params[n] = Field{Column: column, Nullable: !column.NotNull}
+		}
+	}
+	if q.Params, err = orderedParams(q.SQL, params); err != nil {
+		return err
+	}
+
+	if q.Results, err = results(q.SQL, tables); err != nil {
+		return err
+	}
+	if q.Cmd != "exec" && len(q.Results) == 0 {
+		return fmt.Errorf(":%s query returns no columns", q.Cmd)
+	}
+	return nil
+}
+
+func referencedTables(sql string, schema Schema) ([]queryTable, error) {
+	var tables []queryTable
+	for _, match := range tableRef.FindAllStringSubmatch(sql, -1) {
+		table, ok := schema.Table(match[3])
+		if !ok {
+			return nil, fmt.Errorf("unknown table %s", match[3])
+		}
+		alias := match[4]
+		if clauseWords[strings.ToUpper(alias)] {
+			alias = ""
+		}
+		tables = append(tables, queryTable{table: table, alias: alias, nullable: match[1] != ""})
+		if strings.EqualFold(match[1], "FULL") {
+			for i := range tables {
+				tables[i].nullable = true
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no table referenced")
+	}
+	return tables, nil
+}
+
+// resolve finds the column named by alias.name among the tables of a query,
+// or by name alone when a single table has it.
+func resolve(tables []queryTable, alias string, name string) (Column, bool, error) {
+	var found []queryTable
+	for _, table := range tables {
+		if alias != "" && !strings.EqualFold(table.alias, alias) && !strings.EqualFold(table.table.Name, alias) {
+			continue
+		}
+		if _, ok := table.table.Column(name); ok {
+			found = append(found, table)
+		}
+	}
+	switch {
+	case len(found) == 0 && alias != "":
+		return Column{}, false, fmt.Errorf("unknown column %s.%s", alias, name)
+	case len(found) == 0:
+		return Column{}, false, fmt.Errorf("unknown column %s", name)
+	case len(found) > 1 && found[0].table != found[1].table:
+		return Column{}, false, fmt.Errorf("ambiguous column %s", name)
+	}
+	column, _ := found[0].table.Column(name)
+	return column, found[0].nullable, nil
+}
+
+// insertParams types the placeholders of VALUES by the column list.
+func insertParams(sql string, table *Table, params map[int]Field) error {
+	columns, _, err := parenBody(sql, insertInto.FindStringIndex(sql)[1]-1)
+	if err != nil {
+		return err
+	}
+	location := valuesWord.FindStringIndex(sql)
+	if location == nil {
+		return fmt.Errorf("INSERT without VALUES")
+	}
+	values, _, err := parenBody(sql, location[1]-1)
+	if err != nil {
+		return err
+	}
+
+	names, exprs := splitTop(columns), splitTop(values)
+	if len(names) != len(exprs) {
+		return fmt.Errorf("%d columns but %d values", len(names), len(exprs))
+	}
+	for i, expr := range exprs {
+		match := placeholder.FindStringSubmatch(expr)
+		if match == nil {
+			continue
+		}
+		column, ok := table.Column(names[i])
+		if !ok {
+			return fmt.Errorf("unknown column %s.%s", table.Name, names[i])
+		}
+		n, _ := strconv.Atoi(match[1])
+		params[n] = Field{Column: column, Nullable: !column.NotNull}
+	}
+	return nil
+}
+
+// orderedParams checks that every placeholder was typed and that they run
+// from $1 without gaps, and names them after their columns.
+func orderedParams(sql string, params map[int]Field) ([]Field, error) {
+	used := make(map[int]bool)
+	for _, match := range anyPlaceholder.FindAllStringSubmatch(sql, -1) {
+		n, _ := strconv.Atoi(match[1])
+		used[n] = true
+	}
+	numbers := make([]int, 0, len(used))
+	for n := range used {
+		if _, ok := params[n]; !ok {
+			return nil, fmt.Errorf("cannot infer the type of $%d", n)
+		}
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	fields := make([]Field, len(numbers))
+	names := make(map[string]int)
+	for i, n := range numbers {
+		if n != i+1 {
+			return nil, fmt.Errorf("placeholder $%d is missing", i+1)
+		}
+		field := params[n]
+		field.Name = uniqueName(names, goName(field.Column.Name))
+		fields[i] = field
+	}
+	return fields, nil
+}
+
+// uniqueName numbers repeated names the way sqlc does: ID, ID_2.
+func uniqueName(names map[string]int, name string) string {
+	names[name]++
+	if count := names[name]; count > 1 {
+		return fmt.Sprintf("%s_%d", name, count)
+	}
+	return name
+}
+
+// results types the select list of a SELECT or the RETURNING list of a
+// write.
+func results(sql string, tables []queryTable) ([]Field, error) {
+	var list string
+	if start := keywordIndex(sql, "SELECT"); start == 0 {
+		end := keywordIndex(sql, "FROM")
+		if end < 0 {
+			return nil, fmt.Errorf("SELECT without FROM")
+		}
+		list = sql[len("SELECT"):end]
+	} else if start := keywordIndex(sql, "RETURNING"); start >= 0 {
+		list = sql[start+len("RETURNING"):]
+	} else {
+		return nil, nil
+	}
+
+	var fields []Field
+	names := make(map[string]bool)
+	for _, item := range splitTop(list) {
+		match := resultItem.FindStringSubmatch(item)
+		if match == nil || match[2] == "*" {
+			return nil, fmt.Errorf("unsupported result %q: select plain columns", item)
+		}
+		column, nullable, err := resolve(tables, match[1], match[2])
+		if err != nil {
+			return nil, err
+		}
+		name := column.Name
+		if match[3] != "" {
+			name = match[3]
+		}
+		field := Field{Name: goName(name), Column: column, Nullable: nullable || !column.NotNull}
+		if names[field.Name] {
+			return nil, fmt.Errorf("result %s selected twice: rename one with AS", field.Name)
+		}
+		names[field.Name] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// keywordIndex returns the index of the first occurrence of keyword outside
+// parentheses, or -1.
+func keywordIndex(sql string, keyword string) int {
+	upper := strings.ToUpper(sql)
+	depth := 0
+	for i := 0; i < len(upper); i++ {
+		switch upper[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(upper[i:], keyword) &&
+				(i == 0 || !isWordByte(upper[i-1])) &&
+				(i+len(keyword) == len(upper) || !isWordByte(upper[i+len(keyword)])) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z'
+}
@@ -0,0 +1,179 @@
+// Package sqlgen generates typed Go functions from annotated SQL queries, in
+// the style of sqlc. The column types come from the CREATE TABLE statements of
+// a schema; each query is preceded by a name annotation:
+//
+//	-- name: DeleteProject :exec
+//	DELETE FROM PROJECTS WHERE ID = $1;
+//
+// :one returns a single row, :many a slice of rows and :exec only an error.
+// A parameter takes the type of the column it is compared with, assigned to
+// or inserted into, and a result the type of the column it selects, nullable
+// when the column allows NULL or its table is on the right of a LEFT JOIN.
+package sqlgen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Column is a column of a table in the schema.
+type Column struct {
+	Name    string
+	Type    string
+	NotNull bool
+}
+
+// Table is a table of the schema with its columns in declaration order.
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Column looks up a column by name, ignoring case.
+func (t *Table) Column(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// Schema holds the tables of a schema by upper-case name.
+type Schema map[string]*Table
+
+// Table looks up a table by name, ignoring case.
+func (s Schema) Table(name string) (*Table, bool) {
+	table, ok := s[strings.ToUpper(name)]
+	return table, ok
+}
+
+var (
+	lineComment = regexp.MustCompile(`--[^\n]*`)
+	createTable = regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)\s*\(`)
+	primaryKey  = regexp.MustCompile(`(?i)^PRIMARY\s+KEY\s*\(([^)]*)\)`)
+)
+
+// Words that end the type of a column definition.
+var constraintWords = map[string]bool{
+	"NOT": true, "NULL": true, "PRIMARY": true, "REFERENCES": true, "DEFAULT": true,
+	"UNIQUE": true, "CHECK": true, "CONSTRAINT": true, "GENERATED": true, "COLLATE": true,
+}
+
+// ParseSchema reads the CREATE TABLE statements of src. Views and other
+// statements are skipped.
+func ParseSchema(src string) (Schema, error) {
+	src = lineComment.ReplaceAllString(src, "")
+	schema := make(Schema)
+	for _, match := range createTable.FindAllStringSubmatchIndex(src, -1) {
+		name := src[match[2]:match[3]]
+		body, _, err := parenBody(src, match[1]-1)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+		table, err := parseTable(name, body)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+		schema[strings.ToUpper(name)] = table
+	}
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("no CREATE TABLE statements found")
+	}
+	return schema, nil
+}
+
+func parseTable(name string, body string) (*Table, error) {
+	table := &Table{Name: name}
+	var keys []string
+	for _, definition := range splitTop(body) {
+		if match := primaryKey.FindStringSubmatch(definition); match != nil {
+			keys = append(keys, splitTop(match[1])...)
+			continue
+		}
+		words := strings.Fields(definition)
+		switch strings.ToUpper(words[0]) {
+		case "CONSTRAINT", "FOREIGN", "UNIQUE", "CHECK", "EXCLUDE":
+			continue
+		}
+		if len(words) < 2 {
+			return nil, fmt.Errorf("column %s has no type", words[0])
+		}
+
+		column := Column{Name: words[0]}
+		typeWords := []string{}
+		for _, word := range words[1:] {
+			if constraintWords[strings.ToUpper(word)] {
+				break
+			}
+			typeWords = append(typeWords, word)
+		}
+		column.Type = strings.ToUpper(strings.Join(typeWords, " "))
+		upper := strings.ToUpper(strings.Join(words, " "))
+		column.NotNull = strings.Contains(upper, "NOT NULL") || strings.Contains(upper, "PRIMARY KEY")
+		table.Columns = append(table.Columns, column)
+	}
+
+	for _, key := range keys {
+		found := false
+		for i := range table.Columns {
+			if strings.EqualFold(table.Columns[i].Name, key) {
+				table.Columns[i].NotNull = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("primary key column %s is not defined", key)
+		}
+	}
+	return table, nil
+}
+
+// parenBody returns the text between the parenthesis at open and the one
+// closing it, and the index just after the closing one.
+func parenBody(s string, open int) (string, int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[open+1 : i], i + 1, nil
+			}
+		}
+	}
+	return "", 0, fmt.Errorf("unbalanced parentheses")
+}
+
+// splitTop splits s on the commas outside parentheses and trims the parts,
+// dropping empty ones.
+func splitTop(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			switch s[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		if part := strings.TrimSpace(s[start:i]); part != "" {
+			parts = append(parts, part)
+		}
+		start = i + 1
+	}
+	return parts
+}
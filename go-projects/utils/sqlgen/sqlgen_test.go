@@ -0,0 +1,185 @@
+package sqlgen
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+-- Parents
+CREATE TABLE PARENTS (
+    ID     INTEGER PRIMARY KEY,
+    NAME   VARCHAR(255) NOT NULL,
+    BUDGET DECIMAL(12, 2)
+);
+
+CREATE TABLE CHILDREN (
+    ID        INTEGER,
+    PARENT_ID INTEGER REFERENCES PARENTS(ID) ON DELETE CASCADE,
+    BORN      DATE NOT NULL,
+    PRIMARY KEY (ID)
+);
+
+CREATE VIEW PARENT_VIEW AS SELECT ID FROM PARENTS;
+`
+
+func parseTestSchema(t *testing.T) Schema {
+	t.Helper()
+	schema, err := ParseSchema(testSchema)
+	if err != nil {
+		t.Fatalf("ParseSchema failed: %v", err)
+	}
+	return schema
+}
+
+func TestParseSchema(t *testing.T) {
+	schema := parseTestSchema(t)
+	if len(schema) != 2 {
+		t.Fatalf("Expected 2 tables, got %d", len(schema))
+	}
+	parents, _ := schema.Table("parents")
+	if budget, _ := parents.Column("BUDGET"); budget.Type != "DECIMAL(12, 2)" || budget.NotNull {
+		t.Errorf("Unexpected BUDGET column: %+v", budget)
+	}
+	children, _ := schema.Table("CHILDREN")
+	if id, _ := children.Column("ID"); !id.NotNull {
+		t.Errorf("Expected the table primary key to be NOT NULL: %+v", id)
+	}
+	if parent, _ := children.Column("PARENT_ID"); parent.Type != "INTEGER" || parent.NotNull {
+		t.Errorf("Unexpected PARENT_ID column: %+v", parent)
+	}
+}
+
+func TestParseQueries(t *testing.T) {
+	queries, err := ParseQueries(`
+-- name: InsertChild :one
+-- InsertChild adds a child.
+INSERT INTO CHILDREN (ID, PARENT_ID, BORN) VALUES ($1, $2, $3) RETURNING ID;
+
+-- name: ReadParent :many
+SELECT p.ID, p.NAME, c.ID AS CHILD_ID, c.BORN
+FROM PARENTS p LEFT JOIN CHILDREN c ON c.PARENT_ID = p.ID
+WHERE p.ID = $1;
+
+-- name: RenameParent :exec
+UPDATE PARENTS SET NAME = $1 WHERE ID = $2;
+`, parseTestSchema(t))
+	if err != nil {
+		t.Fatalf("ParseQueries failed: %v", err)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("Expected 3 queries, got %d", len(queries))
+	}
+
+	insert := queries[0]
+	if insert.Cmd != "one" || len(insert.Doc) != 1 || insert.Doc[0] != "InsertChild adds a child." {
+		t.Errorf("Unexpected annotation: %+v", insert)
+	}
+	if len(insert.Params) != 3 || insert.Params[1].Name != "ParentID" || !insert.Params[1].Nullable {
+		t.Errorf("Unexpected insert params: %+v", insert.Params)
+	}
+	if goType, _ := insert.Params[2].GoType(); goType != "time.Time" {
+		t.Errorf("Expected BORN to be a time.Time, got %s", goType)
+	}
+
+	read := queries[1]
+	var names []string
+	for _, field := range read.Results {
+		goType, _ := field.GoType()
+		names = append(names, field.Name+" "+goType)
+	}
+	if got := strings.Join(names, ", "); got != "ID int32, Name string, ChildID sql.NullInt32, Born sql.NullTime" {
+		t.Errorf("Expected the LEFT JOINed columns to be nullable, got %s", got)
+	}
+	if len(read.Params) != 1 || read.Params[0].Name != "ID" {
+		t.Errorf("Unexpected read params: %+v", read.Params)
+	}
+
+	rename := queries[2]
+	if len(rename.Params) != 2 || rename.Params[0].Name != "Name" || rename.Params[1].Name != "ID" || rename.Results != nil {
+		t.Errorf("Unexpected update: %+v", rename)
+	}
+}
+
+func TestParseQueriesErrors(t *testing.T) {
+	schema := parseTestSchema(t)
+	cases := map[string]string{
+		"SELECT ID FROM PARENTS p JOIN CHILDREN c ON c.PARENT_ID = p.ID":         "ambiguous column ID",
+		"SELECT p.ID, c.ID FROM PARENTS p JOIN CHILDREN c ON c.PARENT_ID = p.ID": "selected twice",
+		"SELECT ID FROM PARENTS LIMIT $1":                                        "cannot infer the type of $1",
+		"SELECT ID FROM PARENTS WHERE ID = $2":                                   "placeholder $1 is missing",
+		"SELECT * FROM PARENTS":                                                  "unsupported result",
+		"SELECT ID FROM ORPHANS":                                                 "unknown table ORPHANS",
+	}
+	for sql, expected := range cases {
+		_, err := ParseQueries("-- name: Broken :many\n"+sql, schema)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", sql, expected, err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	queries, err := ParseQueries(`
+-- name: CountChildren :one
+SELECT ID FROM CHILDREN WHERE PARENT_ID = $1;
+
+-- name: DeleteParent :exec
+DELETE FROM PARENTS WHERE ID = $1;
+`, parseTestSchema(t))
+	if err != nil {
+		t.Fatalf("ParseQueries failed: %v", err)
+	}
+	source, err := Generate("children", "children.sql", queries)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	code := string(source)
+	for _, expected := range []string{
+		"package children",
+		"func (q *Queries) CountChildren(ctx context.Context, parentID sql.NullInt32) (int32, error) {",
+		"func (q *Queries) DeleteParent(ctx context.Context, id int32) error {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected the generated code to contain %q:\n%s", expected, code)
+		}
+	}
+	if strings.Contains(code, `"time"`) {
+		t.Errorf("Expected no time import without time columns")
+	}
+}
+
+// TestGeneratedUpToDate fails when tests/SQLGen/db/queries.go was not
+// regenerated after a change of its queries or of the schema.
+func TestGeneratedUpToDate(t *testing.T) {
+	schemaSQL, err := os.ReadFile("../../../database/schema.sql")
+	if err != nil {
+		t.Skipf("Schema not available: %v", err)
+	}
+	schema, err := ParseSchema(string(schemaSQL))
+	if err != nil {
+		t.Fatalf("ParseSchema failed: %v", err)
+	}
+	queriesSQL, err := os.ReadFile("../../tests/SQLGen/db/queries.sql")
+	if err != nil {
+		t.Fatalf("Failed to read queries: %v", err)
+	}
+	queries, err := ParseQueries(string(queriesSQL), schema)
+	if err != nil {
+		t.Fatalf("ParseQueries failed: %v", err)
+	}
+	source, err := Generate("db", "queries.sql", queries)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	current, err := os.ReadFile("../../tests/SQLGen/db/queries.go")
+	if err != nil {
+		t.Fatalf("Failed to read generated code: %v", err)
+	}
+	if string(current) != string(source) {
+		t.Errorf("tests/SQLGen/db/queries.go is out of date: run go generate ./tests/SQLGen/db")
+	}
+}
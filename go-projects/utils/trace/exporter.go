@@ -0,0 +1,132 @@
+package trace
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryExporter keeps spans in memory, for tests and for Summarize.
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []Span
+}
+
+func (e *MemoryExporter) Export(span Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the spans exported so far, in the order they ended.
+func (e *MemoryExporter) Spans() []Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]Span(nil), e.spans...)
+}
+
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = nil
+}
+
+// JSONFileExporter appends spans to a file as JSON Lines. Each span is written
+// when it ends, so nothing is lost if the process exits without Close.
+type JSONFileExporter struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func NewJSONFile(path string) (*JSONFileExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONFileExporter{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Export writes the span. Write errors are dropped, as tracing must not fail
+// the traced code.
+func (e *JSONFileExporter) Export(span Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.encoder.Encode(span)
+}
+
+func (e *JSONFileExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.file.Close()
+}
+
+// ReadJSONFile loads the spans written by a JSONFileExporter.
+func ReadJSONFile(path string) ([]Span, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var spans []Span
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var span Span
+		if err := decoder.Decode(&span); err != nil {
+			return spans, err
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// Summary aggregates the spans of one name. Self is the time not covered by
+// child spans: for a DAO call, the reflection around its SQL statements.
+type Summary struct {
+	Name  string
+	Count int
+	Total time.Duration
+	Self  time.Duration
+}
+
+// Summarize groups spans by name, sorted by total time, longest first.
+func Summarize(spans []Span) []Summary {
+	children := make(map[uint64]time.Duration)
+	for _, span := range spans {
+		if span.Parent != 0 {
+			children[span.Parent] += span.Duration
+		}
+	}
+
+	byName := make(map[string]*Summary)
+	for _, span := range spans {
+		summary, exists := byName[span.Name]
+		if !exists {
+			summary = &Summary{Name: span.Name}
+			byName[span.Name] = summary
+		}
+		summary.Count++
+		summary.Total += span.Duration
+		summary.Self += span.Duration - children[span.ID]
+	}
+
+	summaries := make([]Summary, 0, len(byName))
+	for _, summary := range byName {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Total != summaries[j].Total {
+			return summaries[i].Total > summaries[j].Total
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
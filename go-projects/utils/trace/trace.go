@@ -0,0 +1,162 @@
+// Package trace records nested, timed spans for repository calls and the SQL
+// statements they run, and hands the finished spans to an Exporter.
+//
+// A span is carried by the context returned from Start: spans started, and
+// statements run, with that context become its children.
+package trace
+
+import (
+	"context"
+	"m/utils/querylog"
+	"sync/atomic"
+	"time"
+)
+
+// Span is a finished unit of work.
+type Span struct {
+	ID         uint64                 `json:"id"`
+	Parent     uint64                 `json:"parent,omitempty"`
+	Name       string                 `json:"name"`
+	Start      time.Time              `json:"start"`
+	Duration   time.Duration          `json:"duration"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Exporter receives every span when it ends. It must be safe for concurrent use.
+type Exporter interface {
+	Export(span Span)
+}
+
+// Tracer creates spans. Without an exporter it is disabled and Start returns a
+// nil span, whose methods do nothing.
+type Tracer struct {
+	exporter atomic.Pointer[Exporter]
+	nextID   atomic.Uint64
+}
+
+func New(exporter Exporter) *Tracer {
+	t := &Tracer{}
+	t.SetExporter(exporter)
+	return t
+}
+
+// Default is the tracer used by the repositories. It starts disabled.
+var Default = New(nil)
+
+// SetExporter replaces the exporter of Default; nil disables it.
+func SetExporter(exporter Exporter) {
+	Default.SetExporter(exporter)
+}
+
+// Start opens a span on Default. See Tracer.Start.
+func Start(ctx context.Context, name string, attrs ...interface{}) (context.Context, *ActiveSpan) {
+	return Default.Start(ctx, name, attrs...)
+}
+
+// SetExporter replaces the exporter; nil disables the tracer.
+func (t *Tracer) SetExporter(exporter Exporter) {
+	if exporter == nil {
+		t.exporter.Store(nil)
+		return
+	}
+	t.exporter.Store(&exporter)
+}
+
+func (t *Tracer) Enabled() bool {
+	return t.exporter.Load() != nil
+}
+
+// Start opens a span as a child of the span carried by ctx, and returns a
+// context carrying the new one. attrs are key/value pairs, as in log/slog.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...interface{}) (context.Context, *ActiveSpan) {
+	if !t.Enabled() {
+		return ctx, nil
+	}
+	span := &ActiveSpan{
+		tracer: t,
+		span:   Span{ID: t.nextID.Add(1), Name: name, Start: time.Now(), Attributes: attributes(attrs)},
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.span.Parent = parent.span.ID
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// LogQuery records a statement reported by querylog as a child span of the
+// span carried by ctx, so a Tracer can be passed to querylog.Open.
+func (t *Tracer) LogQuery(ctx context.Context, entry querylog.Entry) {
+	if !t.Enabled() {
+		return
+	}
+	span := Span{
+		ID:       t.nextID.Add(1),
+		Name:     "sql." + entry.Op,
+		Start:    time.Now().Add(-entry.Duration),
+		Duration: entry.Duration,
+		Attributes: map[string]interface{}{
+			"db.statement": entry.Query,
+			"db.rows":      entry.Rows,
+			"caller":       entry.Caller,
+		},
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.Parent = parent.span.ID
+	}
+	if entry.Err != nil {
+		span.Error = entry.Err.Error()
+	}
+	t.export(span)
+}
+
+type spanKey struct{}
+
+// FromContext returns the span carried by ctx, or nil.
+func FromContext(ctx context.Context) *ActiveSpan {
+	span, _ := ctx.Value(spanKey{}).(*ActiveSpan)
+	return span
+}
+
+func (t *Tracer) export(span Span) {
+	if exporter := t.exporter.Load(); exporter != nil {
+		(*exporter).Export(span)
+	}
+}
+
+// ActiveSpan is a span that has not ended yet.
+type ActiveSpan struct {
+	tracer *Tracer
+	span   Span
+}
+
+func (s *ActiveSpan) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	if s.span.Attributes == nil {
+		s.span.Attributes = make(map[string]interface{})
+	}
+	s.span.Attributes[key] = value
+}
+
+// End closes the span and exports it.
+func (s *ActiveSpan) End() {
+	if s == nil {
+		return
+	}
+	s.span.Duration = time.Since(s.span.Start)
+	s.tracer.export(s.span)
+}
+
+func attributes(attrs []interface{}) map[string]interface{} {
+	if len(attrs) == 0 {
+		return nil
+	}
+	result := make(map[string]interface{}, len(attrs)/2)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if key, ok := attrs[i].(string); ok {
+			result[key] = attrs[i+1]
+		}
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"m/utils/querylog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNesting(t *testing.T) {
+	exporter := &MemoryExporter{}
+	tracer := New(exporter)
+
+	ctx, outer := tracer.Start(context.Background(), "ReadProject", "project.id", 1)
+	innerCtx, inner := tracer.Start(ctx, "dao.ReadMultiple")
+	tracer.LogQuery(innerCtx, querylog.Entry{Op: "query", Query: "SELECT 1", Duration: time.Millisecond, Rows: 1})
+	inner.End()
+	tracer.LogQuery(ctx, querylog.Entry{Op: "exec", Query: "DELETE", Err: errors.New("no rows")})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, span := tracer.Start(context.Background(), "other request")
+		span.End()
+	}()
+	wg.Wait()
+	outer.End()
+
+	spans := exporter.Spans()
+	byName := make(map[string]Span)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	if len(spans) != 5 {
+		t.Fatalf("Expected 5 spans, got %+v", spans)
+	}
+	root := byName["ReadProject"]
+	if root.Parent != 0 || root.Attributes["project.id"] != 1 {
+		t.Errorf("Unexpected root span: %+v", root)
+	}
+	if byName["dao.ReadMultiple"].Parent != root.ID {
+		t.Error("Expected the DAO span to be a child of ReadProject")
+	}
+	if byName["sql.query"].Parent != byName["dao.ReadMultiple"].ID || byName["sql.query"].Attributes["db.statement"] != "SELECT 1" {
+		t.Errorf("Expected the statement under dao.ReadMultiple, got %+v", byName["sql.query"])
+	}
+	if failed := byName["sql.exec"]; failed.Parent != root.ID || failed.Error != "no rows" {
+		t.Errorf("Expected the failed statement under ReadProject with its error, got %+v", failed)
+	}
+	if byName["other request"].Parent != 0 {
+		t.Error("Expected a span started from another context not to nest under ReadProject")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	tracer := New(nil)
+	ctx, span := tracer.Start(context.Background(), "ReadProject")
+	if span != nil || FromContext(ctx) != nil {
+		t.Fatalf("Expected a nil span from a disabled tracer, got %+v", span)
+	}
+	span.SetAttribute("ignored", true)
+	span.End()
+}
+
+func TestSummarize(t *testing.T) {
+	spans := []Span{
+		{ID: 1, Name: "ReadProject", Duration: 10 * time.Millisecond},
+		{ID: 2, Parent: 1, Name: "sql.query", Duration: 4 * time.Millisecond},
+		{ID: 3, Parent: 1, Name: "sql.query", Duration: 3 * time.Millisecond},
+	}
+	summaries := Summarize(spans)
+	expected := []Summary{
+		{Name: "ReadProject", Count: 1, Total: 10 * time.Millisecond, Self: 3 * time.Millisecond},
+		{Name: "sql.query", Count: 2, Total: 7 * time.Millisecond, Self: 7 * time.Millisecond},
+	}
+	if len(summaries) != len(expected) || summaries[0] != expected[0] || summaries[1] != expected[1] {
+		t.Errorf("Expected %+v, got %+v", expected, summaries)
+	}
+}
+
+func TestJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	exporter, err := NewJSONFile(path)
+	if err != nil {
+		t.Fatalf("NewJSONFile failed: %v", err)
+	}
+	tracer := New(exporter)
+	ctx, outer := tracer.Start(context.Background(), "InsertProject")
+	_, inner := tracer.Start(ctx, "dao.Create", "table", "PROJECTS")
+	inner.End()
+	outer.End()
+	exporter.Close()
+
+	spans, err := ReadJSONFile(path)
+	if err != nil {
+		t.Fatalf("ReadJSONFile failed: %v", err)
+	}
+	if len(spans) != 2 || spans[0].Name != "dao.Create" || spans[0].Parent != spans[1].ID || spans[0].Attributes["table"] != "PROJECTS" {
+		t.Errorf("Unexpected spans: %+v", spans)
+	}
+}